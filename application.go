@@ -2,20 +2,26 @@ package main
 
 import (
 	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 	"go.uber.org/zap"
 )
 
 // Application contains all components of go-initial-project components.
+// Use [BotApplication], [WorkerApplication] or [CLIApplication] for processes which need only some of them.
 type Application struct {
-	Config *Config
+	Config         *Config
+	ConfigReloader *ConfigReloader
 
-	ClickHouse  *utils.ClickHouse
-	Logger      *zap.Logger
-	Postgres    *utils.Postgresql
-	RabbitMQ    *utils.RabbitMQ
-	S3          *utils.S3
-	TelegramBot *utils.TelegramBot
+	ClickHouse      *utils.ClickHouse
+	Logger          *zap.Logger
+	LoggerLevels    *utils.LoggerLevels
+	AdminHTTPServer *utils.AdminHTTPServer
+	Postgres        *utils.Postgresql
+	RabbitMQ        *utils.RabbitMQ
+	S3              *utils.S3
+	TelegramBot     *utils.TelegramBot
+	CircuitBreakers *tools.CircuitBreakers
 
 	StatManager        *managers.StatManager
 	TelegramBotManager *managers.TelegramBotManager
@@ -27,28 +33,85 @@ type Application struct {
 // Using for configuring with wire.
 func NewApplication(
 	cfg *Config,
+	configReloader *ConfigReloader,
 
 	clickHouse *utils.ClickHouse,
 	logger *zap.Logger,
+	loggerLevels *utils.LoggerLevels,
+	adminHTTPServer *utils.AdminHTTPServer,
 	postgres *utils.Postgresql,
 	rabbitmq *utils.RabbitMQ,
 	s3 *utils.S3,
 	telegramBot *utils.TelegramBot,
+	circuitBreakers *tools.CircuitBreakers,
 
 	statManager *managers.StatManager,
 	telegramBotManager *managers.TelegramBotManager,
 	userAccountManager *managers.UserAccountManager,
 	s3Manager *managers.S3Manager,
 ) *Application {
+	configReloader.SubscribeTelegramBotManager(telegramBotManager)
+	configReloader.SubscribeS3Manager(s3Manager)
+
 	return &Application{
-		Config: cfg,
+		Config:         cfg,
+		ConfigReloader: configReloader,
+
+		ClickHouse:      clickHouse,
+		Logger:          logger.Named("Application"),
+		LoggerLevels:    loggerLevels,
+		AdminHTTPServer: adminHTTPServer,
+		Postgres:        postgres,
+		RabbitMQ:        rabbitmq,
+		S3:              s3,
+		TelegramBot:     telegramBot,
+		CircuitBreakers: circuitBreakers,
+
+		StatManager:        statManager,
+		TelegramBotManager: telegramBotManager,
+		UserAccountManager: userAccountManager,
+		S3Manager:          s3Manager,
+	}
+}
+
+// BotApplication contains components of a Telegram bot process.
+// RabbitMQ is not constructed.
+type BotApplication struct {
+	Config          *Config
+	ConfigReloader  *ConfigReloader
+	Logger          *zap.Logger
+	LoggerLevels    *utils.LoggerLevels
+	AdminHTTPServer *utils.AdminHTTPServer
+
+	StatManager        *managers.StatManager
+	TelegramBotManager *managers.TelegramBotManager
+	UserAccountManager *managers.UserAccountManager
+	S3Manager          *managers.S3Manager
+}
 
-		ClickHouse:  clickHouse,
-		Logger:      logger.Named("Application"),
-		Postgres:    postgres,
-		RabbitMQ:    rabbitmq,
-		S3:          s3,
-		TelegramBot: telegramBot,
+// NewBotApplication creates a new instance of BotApplication.
+// Using for configuring with wire.
+func NewBotApplication(
+	cfg *Config,
+	configReloader *ConfigReloader,
+	logger *zap.Logger,
+	loggerLevels *utils.LoggerLevels,
+	adminHTTPServer *utils.AdminHTTPServer,
+
+	statManager *managers.StatManager,
+	telegramBotManager *managers.TelegramBotManager,
+	userAccountManager *managers.UserAccountManager,
+	s3Manager *managers.S3Manager,
+) *BotApplication {
+	configReloader.SubscribeTelegramBotManager(telegramBotManager)
+	configReloader.SubscribeS3Manager(s3Manager)
+
+	return &BotApplication{
+		Config:          cfg,
+		ConfigReloader:  configReloader,
+		Logger:          logger.Named("BotApplication"),
+		LoggerLevels:    loggerLevels,
+		AdminHTTPServer: adminHTTPServer,
 
 		StatManager:        statManager,
 		TelegramBotManager: telegramBotManager,
@@ -56,3 +119,72 @@ func NewApplication(
 		S3Manager:          s3Manager,
 	}
 }
+
+// WorkerApplication contains components of a queue worker process.
+// Telegram and S3 are not constructed.
+type WorkerApplication struct {
+	Config          *Config
+	ConfigReloader  *ConfigReloader
+	Logger          *zap.Logger
+	LoggerLevels    *utils.LoggerLevels
+	AdminHTTPServer *utils.AdminHTTPServer
+
+	Postgres *utils.Postgresql
+	RabbitMQ *utils.RabbitMQ
+
+	StatManager        *managers.StatManager
+	UserAccountManager *managers.UserAccountManager
+}
+
+// NewWorkerApplication creates a new instance of WorkerApplication.
+// Using for configuring with wire.
+func NewWorkerApplication(
+	cfg *Config,
+	configReloader *ConfigReloader,
+	logger *zap.Logger,
+	loggerLevels *utils.LoggerLevels,
+	adminHTTPServer *utils.AdminHTTPServer,
+
+	postgres *utils.Postgresql,
+	rabbitmq *utils.RabbitMQ,
+
+	statManager *managers.StatManager,
+	userAccountManager *managers.UserAccountManager,
+) *WorkerApplication {
+	return &WorkerApplication{
+		Config:          cfg,
+		ConfigReloader:  configReloader,
+		Logger:          logger.Named("WorkerApplication"),
+		LoggerLevels:    loggerLevels,
+		AdminHTTPServer: adminHTTPServer,
+
+		Postgres: postgres,
+		RabbitMQ: rabbitmq,
+
+		StatManager:        statManager,
+		UserAccountManager: userAccountManager,
+	}
+}
+
+// CLIApplication contains components of a short-lived console command.
+// Only Postgresql is connected, config is not watched for changes.
+type CLIApplication struct {
+	Config *Config
+	Logger *zap.Logger
+
+	Postgres *utils.Postgresql
+}
+
+// NewCLIApplication creates a new instance of CLIApplication.
+// Using for configuring with wire.
+func NewCLIApplication(
+	cfg *Config,
+	logger *zap.Logger,
+	postgres *utils.Postgresql,
+) *CLIApplication {
+	return &CLIApplication{
+		Config:   cfg,
+		Logger:   logger.Named("CLIApplication"),
+		Postgres: postgres,
+	}
+}
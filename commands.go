@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// command is a console command of the application, e.g. `go run . explain-config --json`.
+// Accepts context with the correlation ID of the run and arguments after the command name.
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"config-schema":  configSchemaCommand,
+	"explain-config": explainConfigCommand,
+}
+
+// getDefaultConfigFolder returns config folder in the project root.
+func getDefaultConfigFolder() string {
+	return tools.GetRootPath() + string(os.PathSeparator) + "config"
+}
+
+// explainConfigCommand prints effective config with the source of every value.
+// Secrets are masked.
+func explainConfigCommand(_ context.Context, args []string) error {
+	ew := tools.GetErrorWrapper("explainConfigCommand")
+
+	flags := flag.NewFlagSet("explain-config", flag.ContinueOnError)
+	configFolder := flags.String("config-folder", getDefaultConfigFolder(), "folder with config files")
+	profile := flags.String("profile", "", "config profile, "+ConfigProfileEnvName+" is used if empty")
+	isJSON := flags.Bool("json", false, "print as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return ew(err)
+	}
+
+	resolvedProfile, err := resolveConfigProfile(ConfigProfile(*profile))
+	if err != nil {
+		return ew(err)
+	}
+
+	config := Config{
+		ConfigFolder: *configFolder,
+		Profile:      resolvedProfile,
+	}
+
+	values, err := tools.ExplainConfig(getConfigFiles(*configFolder, resolvedProfile), ConfigEnvPrefix, &config)
+	if err != nil {
+		return ew(err)
+	}
+
+	// paths relative to config folder make output comparable between environments
+	for i := range values {
+		if values[i].Source.File == "" {
+			continue
+		}
+
+		if relativePath, err := filepath.Rel(*configFolder, values[i].Source.File); err == nil {
+			values[i].Source.File = relativePath
+		}
+	}
+
+	if *isJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return ew(encoder.Encode(values))
+	}
+
+	return ew(tools.WriteConfigExplanation(os.Stdout, values))
+}
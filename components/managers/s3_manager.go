@@ -2,6 +2,7 @@ package managers
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -13,9 +14,27 @@ import (
 )
 
 type S3ManagerConfig struct {
-	Bucket  string
-	MaxKeys int32
-	Timeout uint
+	IsEnabled bool
+	Bucket    string
+	MaxKeys   int32
+	Timeout   uint
+	// ConnectRetry is a policy of retrying the connection check, nil means a single attempt.
+	ConnectRetry *tools.RetryConfig
+}
+
+// Validate checks configuration values.
+func (c *S3ManagerConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	v.Required("bucket", c.Bucket)
+	v.Check(c.MaxKeys > 0 && c.MaxKeys <= 1000, "max_keys", "must be between 1 and 1000, got %d", c.MaxKeys)
+	v.Positive("timeout", int64(c.Timeout))
+
+	if c.ConnectRetry != nil {
+		c.ConnectRetry.Validate(v.Section("connect_retry"))
+	}
 }
 
 // S3Manager is a struct for managing files in systems like S3.
@@ -24,38 +43,52 @@ type S3Manager struct {
 	logger              *zap.Logger
 	ErrorWrapperCreator tools.ErrorWrapperCreator
 	S3Client            *utils.S3
+	configMutex         sync.RWMutex
+	circuitBreaker      *tools.CircuitBreaker
 }
 
 // NewS3Manager creates a new instance of S3Manager.
+// Requests to S3 fail fast while it is unhealthy, see [tools.CircuitBreaker].
 // Using for configuring with wire.
 func NewS3Manager(
 	config *S3ManagerConfig,
 	logger *zap.Logger,
 	errorWrapperCreator tools.ErrorWrapperCreator,
 	s3Client *utils.S3,
+	circuitBreakers *tools.CircuitBreakers,
 ) (*S3Manager, error) {
 	s3Manager := &S3Manager{
 		Config:              config,
 		logger:              logger.Named("S3Manager"),
 		ErrorWrapperCreator: errorWrapperCreator.AppendToPrefix("S3Manager"),
 		S3Client:            s3Client,
+		circuitBreaker:      circuitBreakers.Get("s3"),
 	}
 
 	ew := tools.GetErrorWrapper("NewS3Manager")
 
+	if !s3Client.IsEnabled() {
+		s3Manager.logger.Info("S3 is disabled")
+		return s3Manager, nil
+	}
+
 	client, err := s3Manager.GetClient()
 	if err != nil {
 		return nil, ew(err)
 	}
 
-	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		time.Duration(s3Manager.Config.Timeout)*time.Second,
-	)
-	defer cancel()
+	// Testing connection to S3, every attempt has its own timeout.
+	err = tools.Retry(context.Background(), tools.RetryOptions{
+		Config: config.ConnectRetry,
+		Logger: s3Manager.logger.Named("Connect"),
+	}, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, s3Manager.getTimeout())
+		defer cancel()
+
+		_, err := client.ListBuckets(ctx, nil)
 
-	// Testing connection to S3.
-	_, err = client.ListBuckets(ctx, nil)
+		return utils.ClassifyS3Error(err)
+	})
 	if err != nil {
 		return nil, ew(err)
 	}
@@ -63,6 +96,30 @@ func NewS3Manager(
 	return s3Manager, nil
 }
 
+// SetLimits replaces MaxKeys and Timeout of config.
+// Safe for concurrent use with other methods of [S3Manager].
+func (s3Manager *S3Manager) SetLimits(maxKeys int32, timeout uint) {
+	s3Manager.configMutex.Lock()
+	defer s3Manager.configMutex.Unlock()
+
+	s3Manager.Config.MaxKeys = maxKeys
+	s3Manager.Config.Timeout = timeout
+}
+
+func (s3Manager *S3Manager) getTimeout() time.Duration {
+	s3Manager.configMutex.RLock()
+	defer s3Manager.configMutex.RUnlock()
+
+	return time.Duration(s3Manager.Config.Timeout) * time.Second
+}
+
+func (s3Manager *S3Manager) getMaxKeys() int32 {
+	s3Manager.configMutex.RLock()
+	defer s3Manager.configMutex.RUnlock()
+
+	return s3Manager.Config.MaxKeys
+}
+
 // GetClient creates a new S3 client.
 func (s3Manager *S3Manager) GetClient() (*s3.Client, error) {
 	ew := s3Manager.ErrorWrapperCreator.GetMethodWrapper("GetClient")
@@ -84,7 +141,13 @@ type ListObjectsInput struct {
 
 // ListObjects lists all objects in a default bucket.
 func (s3Manager *S3Manager) ListObjects(input ListObjectsInput) ([]types.Object, error) {
-	ew := s3Manager.ErrorWrapperCreator.GetMethodWrapper("ListObjects")
+	return s3Manager.ListObjectsContext(context.Background(), input)
+}
+
+// ListObjectsContext lists all objects in a default bucket.
+// Requests are canceled with ctx, errors contain its correlation ID.
+func (s3Manager *S3Manager) ListObjectsContext(ctx context.Context, input ListObjectsInput) ([]types.Object, error) {
+	ew := s3Manager.ErrorWrapperCreator.GetContextMethodWrapper(ctx, "ListObjects")
 
 	client, err := s3Manager.GetClient()
 	if err != nil {
@@ -96,20 +159,31 @@ func (s3Manager *S3Manager) ListObjects(input ListObjectsInput) ([]types.Object,
 	var continuationToken *string
 
 	bucket := tools.FirstNonEmpty(input.Bucket, s3Manager.Config.Bucket)
-	maxKeys := tools.FirstNonEmpty(input.MaxKeys, s3Manager.Config.MaxKeys)
+	maxKeys := tools.FirstNonEmpty(input.MaxKeys, s3Manager.getMaxKeys())
 
 	for {
-		ctx, cancel := context.WithTimeout(
-			context.Background(),
-			time.Duration(s3Manager.Config.Timeout)*time.Second,
-		)
+		requestCtx, cancel := context.WithTimeout(ctx, s3Manager.getTimeout())
 		defer cancel()
 
-		result, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-			Bucket:            &bucket,
-			MaxKeys:           &maxKeys,
-			ContinuationToken: continuationToken,
-			Prefix:            &input.Prefix,
+		tools.LoggerWithCorrelationID(ctx, s3Manager.logger).Debug("Listing objects",
+			zap.String("bucket", bucket),
+			zap.String("prefix", input.Prefix),
+			zap.Int("listed", len(objectsList)),
+		)
+
+		var result *s3.ListObjectsV2Output
+
+		err = s3Manager.circuitBreaker.Execute(requestCtx, func(ctx context.Context) error {
+			var err error
+
+			result, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket:            &bucket,
+				MaxKeys:           &maxKeys,
+				ContinuationToken: continuationToken,
+				Prefix:            &input.Prefix,
+			})
+
+			return utils.ClassifyS3Error(err)
 		})
 		if err != nil {
 			return objectsList, ew(err)
@@ -1,6 +1,7 @@
 package managers
 
 import (
+	"context"
 	"time"
 
 	"go.uber.org/zap"
@@ -9,24 +10,36 @@ import (
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 )
 
+// ApplicationStatsCorrelationIDMigration adds the column of [ApplicationStatsModel.CorrelationID]
+// to a table created before it, it must be applied manually if clickhouse.auto_migrate is disabled.
+const ApplicationStatsCorrelationIDMigration = "ALTER TABLE application_stats_models " +
+	"ADD COLUMN IF NOT EXISTS correlation_id String"
+
 // StatManager do CRUD operations with statistics.
 type StatManager struct {
 	logger              *zap.Logger
 	ClickHouse          *utils.ClickHouse
 	ErrorWrapperCreator tools.ErrorWrapperCreator
+	circuitBreaker      *tools.CircuitBreaker
+	// isCorrelationIDStored is false if the table does not have the column yet,
+	// then events are added without correlation IDs
+	isCorrelationIDStored bool
 }
 
 // NewStatManager create new StatManager instance.
+// Calls to ClickHouse fail fast while it is unhealthy, see [tools.CircuitBreaker].
 // Using for configuring with wire.
 func NewStatManager(
 	logger *zap.Logger,
 	clickHouse *utils.ClickHouse,
 	errorWrapperCreator tools.ErrorWrapperCreator,
+	circuitBreakers *tools.CircuitBreakers,
 ) (*StatManager, error) {
 	sm := &StatManager{
 		logger:              logger.Named("StatManager"),
 		ClickHouse:          clickHouse,
 		ErrorWrapperCreator: errorWrapperCreator.AppendToPrefix("StatManager"),
+		circuitBreaker:      circuitBreakers.Get("clickhouse"),
 	}
 
 	ew := tools.GetErrorWrapper("NewStatManager")
@@ -41,28 +54,67 @@ func NewStatManager(
 
 func (sm *StatManager) migrate() error {
 	ew := sm.ErrorWrapperCreator.GetMethodWrapper("migrate")
-	err := sm.ClickHouse.Migrate([]interface{}{&ApplicationStatsModel{}})
 
-	return ew(err)
-}
+	if !sm.ClickHouse.IsEnabled() {
+		return nil
+	}
 
-// Add new event.
-func (sm *StatManager) Add(eventName string, eventDateTime time.Time, eventMessage string) error {
-	ew := sm.ErrorWrapperCreator.GetMethodWrapper("Add")
+	err := sm.ClickHouse.Migrate([]interface{}{&ApplicationStatsModel{}})
+	if err != nil {
+		return ew(err)
+	}
 
 	db, err := sm.ClickHouse.GetConnection()
 	if err != nil {
 		return ew(err)
 	}
 
-	db = db.Create(&ApplicationStatsModel{
-		EventName:     eventName,
-		EventDate:     eventDateTime,
-		EventDateTime: eventDateTime,
-		EventMessage:  eventMessage,
-	})
+	sm.isCorrelationIDStored = db.Migrator().HasColumn(&ApplicationStatsModel{}, "CorrelationID")
+	if !sm.isCorrelationIDStored {
+		sm.logger.Warn("Column of correlation ID is missing in statistics, events are added without it",
+			zap.String("migration", ApplicationStatsCorrelationIDMigration),
+		)
+	}
+
+	return nil
+}
 
-	err = db.Error
+// Add new event.
+func (sm *StatManager) Add(eventName string, eventDateTime time.Time, eventMessage string) error {
+	return sm.AddContext(context.Background(), eventName, eventDateTime, eventMessage)
+}
+
+// AddContext adds new event with the correlation ID of ctx, see [tools.EnsureCorrelationID].
+// The ID is not stored if the table has no column for it, see [ApplicationStatsCorrelationIDMigration].
+func (sm *StatManager) AddContext(
+	ctx context.Context,
+	eventName string,
+	eventDateTime time.Time,
+	eventMessage string,
+) error {
+	ew := sm.ErrorWrapperCreator.GetContextMethodWrapper(ctx, "Add")
+
+	err := sm.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+		db, err := sm.ClickHouse.GetConnection()
+		if err != nil {
+			return err
+		}
+
+		query := db.WithContext(ctx)
+		if !sm.isCorrelationIDStored {
+			query = query.Omit("CorrelationID")
+		}
+
+		err = query.Create(&ApplicationStatsModel{
+			EventName:     eventName,
+			EventDate:     eventDateTime,
+			EventDateTime: eventDateTime,
+			EventMessage:  eventMessage,
+			CorrelationID: tools.CorrelationIDFromContext(ctx),
+		}).Error
+
+		return utils.ClassifyClickHouseError(err)
+	})
 	if err != nil {
 		return ew(err)
 	}
@@ -75,10 +127,16 @@ func (sm *StatManager) AddSimple(eventName string, eventMessage string) error {
 	return sm.Add(eventName, time.Now(), eventMessage)
 }
 
+// AddSimpleContext adds event with current datetime and the correlation ID of ctx.
+func (sm *StatManager) AddSimpleContext(ctx context.Context, eventName string, eventMessage string) error {
+	return sm.AddContext(ctx, eventName, time.Now(), eventMessage)
+}
+
 // ApplicationStatsModel contains statistics data.
 type ApplicationStatsModel struct {
 	EventName     string    `gorm:"type:String"   my_clickhouse:"order_by=1;primary_key=1"`
 	EventDate     time.Time `gorm:"type:date"     my_clickhouse:"order_by=2;primary_key=2"`
 	EventDateTime time.Time `gorm:"type:datetime" my_clickhouse:"order_by=3"`
 	EventMessage  string    `gorm:"type:String"   my_clickhouse:"order_by=4"` // Can be of any size
+	CorrelationID string    `gorm:"type:String"`                              // empty for events added without context
 }
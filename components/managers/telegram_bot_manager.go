@@ -1,26 +1,43 @@
 package managers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"gopkg.in/telebot.v3"
-	"gopkg.in/telebot.v3/middleware"
 
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 )
 
 type TelegramBotManagerConfig struct {
-	Token      string
+	IsEnabled  bool
+	Token      tools.Secret
 	Admins     []int64
 	LongPoller struct {
 		Timeout uint
 	}
 }
 
+// Validate checks configuration values.
+func (c *TelegramBotManagerConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	v.Required("token", c.Token.Value())
+
+	for i, admin := range c.Admins {
+		v.Positive(fmt.Sprintf("admins[%d]", i), admin)
+	}
+}
+
 // TelegramBotManager managing [utils.TelegramBot].
 type TelegramBotManager struct {
 	Config              *TelegramBotManagerConfig
@@ -30,6 +47,7 @@ type TelegramBotManager struct {
 	StatManager         *StatManager
 	UserAccountManager  *UserAccountManager
 	ErrorWrapperCreator tools.ErrorWrapperCreator
+	adminsMutex         sync.RWMutex
 }
 
 // NewTelegramBotManager creates new TelegramBotManager.
@@ -40,6 +58,7 @@ func NewTelegramBotManager(
 	statManager *StatManager,
 	userAccountManager *UserAccountManager,
 	telegramBot *utils.TelegramBot,
+	telegramLogSender *utils.TelegramLogSenderProxy,
 	errorWrapperCreator tools.ErrorWrapperCreator,
 ) (*TelegramBotManager, func(), error) {
 	tbm := &TelegramBotManager{
@@ -53,6 +72,11 @@ func NewTelegramBotManager(
 
 	ew := tools.GetErrorWrapper("NewTelegramBotManager")
 
+	if !telegramBot.IsEnabled() {
+		tbm.logger.Info("Telegram is disabled")
+		return tbm, func() {}, nil
+	}
+
 	err := tbm.createBot()
 	if err != nil {
 		return nil, nil, ew(err)
@@ -60,30 +84,41 @@ func NewTelegramBotManager(
 
 	go tbm.telegramBot.Start()
 
-	return tbm, func() { tbm.telegramBot.Stop() }, nil
+	telegramLogSender.SetSender(tbm)
+
+	return tbm, func() {
+		telegramLogSender.SetSender(nil)
+		tbm.telegramBot.Stop()
+	}, nil
 }
 
 func (t *TelegramBotManager) createBot() error {
 	ew := t.ErrorWrapperCreator.GetMethodWrapper("createBot")
 
-	bot, err := t.TelegramBot.CreateBot(t.Config.Token)
+	bot, err := t.TelegramBot.CreateBot(t.Config.Token.Value())
 	if err != nil {
 		return ew(err)
 	}
 
+	// global middleware is applied only to handlers registered after it
+	bot.Use(t.GetCorrelationMiddleware())
+
 	t.telegramBot = bot
 
 	return nil
 }
 
 // GetBot returns [utils.TelegramBot] instance.
-// If bot is not created, it will be created, but will panic if error occurred.
-func (t *TelegramBotManager) GetBot() *telebot.Bot {
+// If bot is not created, it will be created.
+// Returns [tools.ErrComponentDisabled] if Telegram is disabled.
+func (t *TelegramBotManager) GetBot() (*telebot.Bot, error) {
 	if t.telegramBot == nil {
-		tools.PanicOnError(t.createBot())
+		if err := t.createBot(); err != nil {
+			return nil, t.ErrorWrapperCreator.GetMethodWrapper("GetBot")(err)
+		}
 	}
 
-	return t.telegramBot
+	return t.telegramBot, nil
 }
 
 // GetDefaultSendOptions returns default send options.
@@ -120,11 +155,17 @@ type CommonBotCommandsConfig struct {
 }
 
 // AddCommonCommandsHandlers adds handlers for common bot commands.
-func (t *TelegramBotManager) AddCommonCommandsHandlers(cfg *CommonBotCommandsConfig) {
+// Returns [tools.ErrComponentDisabled] if Telegram is disabled.
+func (t *TelegramBotManager) AddCommonCommandsHandlers(cfg *CommonBotCommandsConfig) error {
+	bot, err := t.GetBot()
+	if err != nil {
+		return t.ErrorWrapperCreator.GetMethodWrapper("AddCommonCommandsHandlers")(err)
+	}
+
 	if cfg.Start.Enabled {
 		ew := t.ErrorWrapperCreator.GetMethodWrapper("start_handler")
 
-		t.GetBot().Handle("/start", func(c telebot.Context) error {
+		bot.Handle("/start", func(c telebot.Context) error {
 			message, err := TelegramStartCommandResponse(&cfg.Start)
 			if err != nil {
 				return ew(err)
@@ -137,7 +178,7 @@ func (t *TelegramBotManager) AddCommonCommandsHandlers(cfg *CommonBotCommandsCon
 	if cfg.Help.Enabled {
 		ew := t.ErrorWrapperCreator.GetMethodWrapper("help_handler")
 
-		t.GetBot().Handle("/help", func(c telebot.Context) error {
+		bot.Handle("/help", func(c telebot.Context) error {
 			args := c.Args()
 
 			message, err := TelegramHelpCommandResponse(&cfg.Help, args)
@@ -148,6 +189,8 @@ func (t *TelegramBotManager) AddCommonCommandsHandlers(cfg *CommonBotCommandsCon
 			return ew(c.Send(message, t.GetDefaultSendOptions()))
 		})
 	}
+
+	return nil
 }
 
 // ErrNoMessage error if no message provided for command.
@@ -208,7 +251,207 @@ func TelegramHelpCommandResponse(cfg *HelpCommandConfig, args []string) (string,
 	return fmt.Sprintf("%s\n\n%s", commandConfig.ShortMessage, commandConfig.DetailMessage), nil
 }
 
+// telegramContextKey is a key of [context.Context] of an update in [telebot.Context].
+const telegramContextKey = "context"
+
+// GetCorrelationMiddleware returns middleware which assigns a correlation ID to every update.
+// Use [TelegramContext] in handlers to pass it to managers.
+// Errors of handlers are logged with the ID and the user gets a reply with its short form, see [tools.ShortCorrelationID].
+// The level of the entry and the reply depend on the category of the error, see [tools.GetErrorCategory].
+// It is added to the bot by [NewTelegramBotManager].
+func (t *TelegramBotManager) GetCorrelationMiddleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			ctx, id := tools.EnsureCorrelationID(context.Background())
+			c.Set(telegramContextKey, ctx)
+
+			err := next(c)
+			if err == nil {
+				return nil
+			}
+
+			logger := tools.LoggerWithCorrelationID(ctx, t.logger)
+			fields := []zap.Field{
+				zap.Int("update_id", c.Update().ID),
+				zap.String("error_code", tools.GetErrorCode(err)),
+				zap.Error(err),
+			}
+
+			// errors caused by users are expected, others have to be fixed
+			switch tools.GetErrorCategory(err) {
+			case tools.ErrorCategoryNotFound, tools.ErrorCategoryForbidden, tools.ErrorCategoryInvalidInput:
+				logger.Warn("Failed to handle update", fields...)
+			default:
+				logger.Error("Failed to handle update", fields...)
+			}
+
+			if err := c.Send(TelegramErrorReply(err, id)); err != nil {
+				logger.Error("Failed to send error reply", zap.Error(err))
+			}
+
+			return nil
+		}
+	}
+}
+
+// TelegramErrorReply returns a reply to a user whose update is failed to handle.
+// The reply contains the user-safe message of err, see [tools.GetUserMessage], and the short correlation ID.
+func TelegramErrorReply(err error, correlationID string) string {
+	return fmt.Sprintf("%s. If you contact support, please specify error ID %s",
+		tools.GetUserMessage(err),
+		tools.ShortCorrelationID(correlationID),
+	)
+}
+
+// TelegramContext returns context of the update with its correlation ID, see [TelegramBotManager.GetCorrelationMiddleware].
+// A context with a new ID is returned if the middleware is not used.
+func TelegramContext(c telebot.Context) context.Context {
+	if ctx, ok := c.Get(telegramContextKey).(context.Context); ok {
+		return ctx
+	}
+
+	ctx, _ := tools.EnsureCorrelationID(context.Background())
+
+	return ctx
+}
+
 // GetAdminOnlyMiddleware returns middleware that checks if user is in admins.
+// Updates from other users are skipped.
+// Admins are checked on every update, so changes made by [TelegramBotManager.SetAdmins] are applied immediately.
 func (t *TelegramBotManager) GetAdminOnlyMiddleware() telebot.MiddlewareFunc {
-	return middleware.Whitelist(t.Config.Admins...)
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if c.Sender() == nil || !t.IsAdmin(c.Sender().ID) {
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// IsAdmin checks if user is in admins.
+func (t *TelegramBotManager) IsAdmin(userID int64) bool {
+	t.adminsMutex.RLock()
+	defer t.adminsMutex.RUnlock()
+
+	return slices.Contains(t.Config.Admins, userID)
+}
+
+// SetAdmins replaces list of admins.
+// Safe for concurrent use with [TelegramBotManager.GetAdminOnlyMiddleware].
+func (t *TelegramBotManager) SetAdmins(admins []int64) {
+	t.adminsMutex.Lock()
+	defer t.adminsMutex.Unlock()
+
+	t.Config.Admins = slices.Clone(admins)
+}
+
+// SendLogAlert sends text to all admins as a plain text, so it is not parsed as markdown.
+// Errors are returned but not logged, see [utils.TelegramLogSender].
+func (t *TelegramBotManager) SendLogAlert(text string) error {
+	t.adminsMutex.RLock()
+	admins := slices.Clone(t.Config.Admins)
+	t.adminsMutex.RUnlock()
+
+	errs := []error{}
+
+	for _, admin := range admins {
+		_, err := t.telegramBot.Send(&telebot.User{ID: admin}, text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("admin %d: %w", admin, err))
+		}
+	}
+
+	return tools.WrapMethodError(errors.Join(errs...), "TelegramBotManager.SendLogAlert")
+}
+
+// GetLogLevelsCommandHandler returns handler of a command for reading and changing levels of logger sinks.
+// It must be registered only for admins, see [TelegramBotManager.GetAdminOnlyMiddleware].
+func (t *TelegramBotManager) GetLogLevelsCommandHandler(levels *utils.LoggerLevels) telebot.HandlerFunc {
+	ew := t.ErrorWrapperCreator.GetMethodWrapper("log_levels_handler")
+
+	return func(c telebot.Context) error {
+		return ew(c.Send(TelegramLogLevelsCommandResponse(levels, c.Args())))
+	}
+}
+
+// TelegramLogLevelsCommandResponse returns levels of logger sinks if args are empty.
+// Otherwise, changes level of a sink, args are: <sink> <level> [revert after, e.g. 10m].
+func TelegramLogLevelsCommandResponse(levels *utils.LoggerLevels, args []string) string {
+	if len(args) == 0 {
+		lines := []string{}
+
+		for _, sinkLevel := range levels.GetLevels() {
+			line := fmt.Sprintf("%s: %s", sinkLevel.Sink, sinkLevel.Level)
+			if sinkLevel.RevertAt != nil {
+				line += fmt.Sprintf(" (until %s)", sinkLevel.RevertAt.Format("2006-01-02 15:04:05"))
+			}
+
+			lines = append(lines, line)
+		}
+
+		if len(lines) == 0 {
+			return "No logger sinks are enabled"
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		return "Usage: <sink> <level> [revert after, e.g. 10m]"
+	}
+
+	var revertAfter time.Duration
+
+	if len(args) == 3 {
+		var err error
+
+		revertAfter, err = time.ParseDuration(args[2])
+		if err != nil || revertAfter <= 0 {
+			return fmt.Sprintf("Invalid duration %q, expected e.g. 10m", args[2])
+		}
+	}
+
+	if err := levels.SetLevel(args[0], args[1], revertAfter); err != nil {
+		return fmt.Sprintf("Level is not changed: %s", errors.Unwrap(err))
+	}
+
+	if revertAfter > 0 {
+		return fmt.Sprintf("Level of %s is set to %s for %s", args[0], args[1], revertAfter)
+	}
+
+	return fmt.Sprintf("Level of %s is set to %s", args[0], args[1])
+}
+
+// GetCircuitBreakersCommandHandler returns handler of a command showing states of circuit breakers.
+// It must be registered only for admins, see [TelegramBotManager.GetAdminOnlyMiddleware].
+func (t *TelegramBotManager) GetCircuitBreakersCommandHandler(circuitBreakers *tools.CircuitBreakers) telebot.HandlerFunc {
+	ew := t.ErrorWrapperCreator.GetMethodWrapper("circuit_breakers_handler")
+
+	return func(c telebot.Context) error {
+		return ew(c.Send(TelegramCircuitBreakersCommandResponse(circuitBreakers)))
+	}
+}
+
+// TelegramCircuitBreakersCommandResponse returns states and counters of circuit breakers, one per line.
+func TelegramCircuitBreakersCommandResponse(circuitBreakers *tools.CircuitBreakers) string {
+	lines := []string{}
+
+	for _, stats := range circuitBreakers.Stats() {
+		line := fmt.Sprintf("%s: %s, failures in a row: %d, successes: %d, failures: %d, rejected: %d",
+			stats.Name, stats.State, stats.ConsecutiveFailures, stats.Successes, stats.Failures, stats.Rejected,
+		)
+		if stats.StateChangedAt != nil {
+			line += fmt.Sprintf(" (since %s)", stats.StateChangedAt.Format("2006-01-02 15:04:05"))
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return "No circuit breakers are used yet"
+	}
+
+	return strings.Join(lines, "\n")
 }
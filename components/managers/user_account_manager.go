@@ -1,6 +1,8 @@
 package managers
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
@@ -12,19 +14,23 @@ type UserAccountManager struct {
 	logger              *zap.Logger
 	Postgresql          *utils.Postgresql
 	ErrorWrapperCreator tools.ErrorWrapperCreator
+	circuitBreaker      *tools.CircuitBreaker
 }
 
 // NewUserAccountManager creates a new user account manager.
+// Queries to PostgreSQL fail fast while it is unhealthy, see [tools.CircuitBreaker].
 // Using for configuring with wire.
 func NewUserAccountManager(
 	logger *zap.Logger,
 	postgresql *utils.Postgresql,
 	errorWrapperCreator tools.ErrorWrapperCreator,
+	circuitBreakers *tools.CircuitBreakers,
 ) (*UserAccountManager, error) {
 	uam := &UserAccountManager{
 		logger:              logger.Named("UserManager"),
 		Postgresql:          postgresql,
 		ErrorWrapperCreator: errorWrapperCreator.AppendToPrefix("UserAccountManager"),
+		circuitBreaker:      circuitBreakers.Get("postgresql"),
 	}
 
 	ew := tools.GetErrorWrapper("NewUserAccountManager")
@@ -40,6 +46,10 @@ func NewUserAccountManager(
 func (m *UserAccountManager) migrate() error {
 	ew := m.ErrorWrapperCreator.GetMethodWrapper("migrate")
 
+	if !m.Postgresql.IsEnabled() {
+		return nil
+	}
+
 	err := m.Postgresql.Migrate([]interface{}{UserAccount{}})
 	if err != nil {
 		return ew(err)
@@ -48,6 +58,46 @@ func (m *UserAccountManager) migrate() error {
 	return nil
 }
 
+// Create saves a new user account, its ID is set after saving.
+func (m *UserAccountManager) Create(ctx context.Context, account *UserAccount) error {
+	ew := m.ErrorWrapperCreator.GetContextMethodWrapper(ctx, "Create")
+
+	err := m.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+		db, err := m.Postgresql.GetConnection()
+		if err != nil {
+			return err
+		}
+
+		return utils.ClassifyPostgresqlError(db.WithContext(ctx).Create(account).Error)
+	})
+	if err != nil {
+		return ew(err)
+	}
+
+	return nil
+}
+
+// GetByID returns the user account with the ID, the error is classified as not found if there is no such account.
+func (m *UserAccountManager) GetByID(ctx context.Context, id uint64) (*UserAccount, error) {
+	ew := m.ErrorWrapperCreator.GetContextMethodWrapper(ctx, "GetByID", "id", id)
+
+	account := &UserAccount{}
+
+	err := m.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+		db, err := m.Postgresql.GetConnection()
+		if err != nil {
+			return err
+		}
+
+		return utils.ClassifyPostgresqlError(db.WithContext(ctx).First(account, id).Error)
+	})
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return account, nil
+}
+
 // UserAccount contains information of a user.
 type UserAccount struct {
 	utils.BasicPostgresqlModel
@@ -0,0 +1,270 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func newTestCircuitBreaker(logger *zap.Logger) (*tools.CircuitBreaker, *testClock) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	return tools.NewCircuitBreaker("clickhouse", &tools.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenMaxCalls: 1,
+	}, logger, clock.Now), clock
+}
+
+func failingOperation(context.Context) error {
+	return errTestTemporary
+}
+
+func succeedingOperation(context.Context) error {
+	return nil
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	breaker, _ := newTestCircuitBreaker(zap.New(core))
+
+	require.ErrorIs(t, breaker.Execute(context.Background(), failingOperation), errTestTemporary)
+	// a success resets consecutive failures
+	require.NoError(t, breaker.Execute(context.Background(), succeedingOperation))
+	require.ErrorIs(t, breaker.Execute(context.Background(), failingOperation), errTestTemporary)
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+
+	require.ErrorIs(t, breaker.Execute(context.Background(), failingOperation), errTestTemporary)
+	assert.Equal(t, tools.CircuitBreakerOpen, breaker.State())
+
+	called := false
+	err := breaker.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	require.ErrorIs(t, err, tools.ErrCircuitBreakerOpen)
+	assert.False(t, called)
+	assert.Equal(t, "circuit breaker is open: clickhouse", err.Error())
+	assert.True(t, tools.IsRetryableError(err))
+	assert.Equal(t, "circuit_breaker.open", tools.GetErrorCode(err))
+
+	opened := observed.FilterMessage("Circuit breaker opened").All()
+	require.Len(t, opened, 1)
+	assert.Equal(t, "clickhouse", opened[0].ContextMap()["circuit_breaker"])
+	assert.Equal(t, int64(2), opened[0].ContextMap()["consecutive_failures"])
+	assert.Equal(t, zapcore.WarnLevel, opened[0].Level)
+
+	stats := breaker.Stats()
+	assert.Equal(t, tools.CircuitBreakerOpen, stats.State)
+	assert.Equal(t, uint64(1), stats.Successes)
+	assert.Equal(t, uint64(3), stats.Failures)
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.Equal(t, uint64(1), stats.StateChanges)
+	assert.NotNil(t, stats.StateChangedAt)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	breaker, clock := newTestCircuitBreaker(zap.New(core))
+
+	for range 2 {
+		_ = breaker.Execute(context.Background(), failingOperation)
+	}
+
+	clock.Set(clock.Now().Add(time.Minute))
+	assert.Equal(t, tools.CircuitBreakerHalfOpen, breaker.State())
+
+	// a failed trial call opens the breaker again
+	require.ErrorIs(t, breaker.Execute(context.Background(), failingOperation), errTestTemporary)
+	assert.Equal(t, tools.CircuitBreakerOpen, breaker.State())
+
+	clock.Set(clock.Now().Add(time.Minute))
+
+	// only HalfOpenMaxCalls trial calls are allowed at the same time
+	var concurrentErr error
+
+	require.NoError(t, breaker.Execute(context.Background(), func(ctx context.Context) error {
+		concurrentErr = breaker.Execute(ctx, succeedingOperation)
+		return nil
+	}))
+	require.ErrorIs(t, concurrentErr, tools.ErrCircuitBreakerOpen)
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+	assert.Equal(t, 0, breaker.Stats().ConsecutiveFailures)
+
+	assert.Equal(t, 2, observed.FilterMessage("Circuit breaker is half-open, trying calls").Len())
+	assert.Equal(t, 1, observed.FilterMessage("Circuit breaker closed").Len())
+}
+
+func TestCircuitBreakerRecordsPanicAsFailure(t *testing.T) {
+	breaker, clock := newTestCircuitBreaker(zap.NewNop())
+
+	for range 2 {
+		_ = breaker.Execute(context.Background(), failingOperation)
+	}
+
+	clock.Set(clock.Now().Add(time.Minute))
+	assert.Equal(t, tools.CircuitBreakerHalfOpen, breaker.State())
+
+	// the panic is not recovered, but the trial call is finished
+	assert.PanicsWithValue(t, "unexpected", func() {
+		_ = breaker.Execute(context.Background(), func(context.Context) error { panic("unexpected") })
+	})
+	assert.Equal(t, tools.CircuitBreakerOpen, breaker.State())
+	assert.Equal(t, uint64(3), breaker.Stats().Failures)
+
+	clock.Set(clock.Now().Add(time.Minute))
+	require.NoError(t, breaker.Execute(context.Background(), succeedingOperation))
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+}
+
+func TestCircuitBreakerDoesNotRecordCanceledCalls(t *testing.T) {
+	breaker, clock := newTestCircuitBreaker(zap.NewNop())
+
+	canceledOperation := func(context.Context) error { return context.Canceled }
+
+	// cancellation does not reset consecutive failures
+	_ = breaker.Execute(context.Background(), failingOperation)
+	require.ErrorIs(t, breaker.Execute(context.Background(), canceledOperation), context.Canceled)
+	assert.Equal(t, 1, breaker.Stats().ConsecutiveFailures)
+
+	_ = breaker.Execute(context.Background(), failingOperation)
+	assert.Equal(t, tools.CircuitBreakerOpen, breaker.State())
+
+	clock.Set(clock.Now().Add(time.Minute))
+	assert.Equal(t, tools.CircuitBreakerHalfOpen, breaker.State())
+
+	// the canceled trial call neither closes nor opens the breaker and frees the trial slot
+	require.ErrorIs(t, breaker.Execute(context.Background(), canceledOperation), context.Canceled)
+	assert.Equal(t, tools.CircuitBreakerHalfOpen, breaker.State())
+	assert.Equal(t, uint64(0), breaker.Stats().Successes)
+	assert.Equal(t, uint64(2), breaker.Stats().Failures)
+
+	require.NoError(t, breaker.Execute(context.Background(), succeedingOperation))
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresRequestErrors(t *testing.T) {
+	breaker, _ := newTestCircuitBreaker(zap.NewNop())
+
+	for _, err := range []error{
+		tools.ClassifyError(errors.New("no rows"), tools.ErrorCategoryNotFound, "postgres.no_rows", ""),
+		tools.ClassifyError(errors.New("denied"), tools.ErrorCategoryForbidden, "s3.AccessDenied", ""),
+		tools.ClassifyError(errors.New("duplicate"), tools.ErrorCategoryInvalidInput, "postgres.23505", ""),
+		context.Canceled,
+		tools.NewErrComponentDisabled("clickhouse"),
+		tools.ErrCircuitBreakerOpen,
+	} {
+		assert.False(t, tools.IsCircuitBreakerFailure(err), err.Error())
+
+		for range 3 {
+			require.ErrorIs(t, breaker.Execute(context.Background(), func(context.Context) error { return err }), err)
+		}
+	}
+
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+	assert.True(t, tools.IsCircuitBreakerFailure(errTestTemporary))
+	assert.True(t, tools.IsCircuitBreakerFailure(errors.New("unknown")))
+}
+
+func TestCircuitBreakerWithoutConfigNeverOpens(t *testing.T) {
+	breaker := tools.NewCircuitBreaker("s3", nil, zap.NewNop(), nil)
+
+	for range 100 {
+		_ = breaker.Execute(context.Background(), failingOperation)
+	}
+
+	assert.Equal(t, tools.CircuitBreakerClosed, breaker.State())
+	assert.Equal(t, uint64(100), breaker.Stats().Failures)
+}
+
+func TestCircuitBreakerConfigValidation(t *testing.T) {
+	v := tools.NewConfigValidator()
+	(&tools.CircuitBreakerConfig{}).Validate(v.Section("circuit_breaker"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+
+	for _, path := range []string{
+		"circuit_breaker.failure_threshold", "circuit_breaker.open_timeout", "circuit_breaker.half_open_max_calls",
+	} {
+		assert.Contains(t, err.Error(), path)
+	}
+}
+
+func newTestCircuitBreakers() *tools.CircuitBreakers {
+	breakers := tools.NewCircuitBreakers(&tools.CircuitBreakerConfig{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Hour,
+		HalfOpenMaxCalls: 1,
+	}, zap.NewNop())
+
+	_ = breakers.Get("s3").Execute(context.Background(), succeedingOperation)
+	_ = breakers.Get("clickhouse").Execute(context.Background(), failingOperation)
+
+	return breakers
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	breakers := newTestCircuitBreakers()
+
+	assert.Same(t, breakers.Get("s3"), breakers.Get("s3"))
+
+	stats := breakers.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "clickhouse", stats[0].Name)
+	assert.Equal(t, tools.CircuitBreakerOpen, stats[0].State)
+	assert.Equal(t, "s3", stats[1].Name)
+	assert.Equal(t, tools.CircuitBreakerClosed, stats[1].State)
+	assert.Nil(t, stats[1].StateChangedAt)
+}
+
+func TestCircuitBreakersHTTPHandler(t *testing.T) {
+	server, cleanup, err := utils.NewAdminHTTPServer(
+		&utils.AdminHTTPConfig{Token: "admin-token"},
+		zap.NewNop(),
+		newTestLoggerLevels(t),
+		tools.NewErrorWrapperCreator(),
+		newTestCircuitBreakers(),
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	request := httptest.NewRequest(http.MethodGet, "/circuit-breakers", nil)
+	request.Header.Set("Authorization", "Bearer admin-token")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `{"name":"clickhouse","state":"open","consecutive_failures":1,`)
+	assert.Contains(t, recorder.Body.String(), `{"name":"s3","state":"closed","consecutive_failures":0,"successes":1,`)
+
+	request = httptest.NewRequest(http.MethodPost, "/circuit-breakers", nil)
+	request.Header.Set("Authorization", "Bearer admin-token")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestTelegramCircuitBreakersCommandResponse(t *testing.T) {
+	assert.Equal(t, "No circuit breakers are used yet",
+		managers.TelegramCircuitBreakersCommandResponse(tools.NewCircuitBreakers(nil, zap.NewNop())),
+	)
+
+	response := managers.TelegramCircuitBreakersCommandResponse(newTestCircuitBreakers())
+	assert.Contains(t, response, "clickhouse: open, failures in a row: 1, successes: 0, failures: 1, rejected: 0 (since ")
+	assert.Contains(t, response, "\ns3: closed, failures in a row: 0, successes: 1, failures: 0, rejected: 0")
+}
@@ -0,0 +1,109 @@
+package tests_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+func TestRunCommand(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+
+	result, err := tools.RunCommand(context.Background(), "sh", []string{"-c", `cat; echo "$GREETING"; echo oops >&2`},
+		tools.CommandOptions{
+			Env:         []string{"GREETING=hello"},
+			Stdin:       strings.NewReader("input\n"),
+			StdoutLevel: zapcore.DebugLevel,
+			StderrLevel: zapcore.WarnLevel,
+			Logger:      zap.New(core),
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "input\nhello\n", result.Stdout)
+	assert.Equal(t, "oops\n", result.Stderr)
+	assert.False(t, result.StdoutTruncated)
+	assert.Positive(t, result.Duration)
+
+	lines := observed.FilterMessage("Command output").All()
+	require.Len(t, lines, 3)
+
+	levels := map[string]zapcore.Level{}
+	for _, line := range lines {
+		levels[line.ContextMap()["line"].(string)] = line.Level
+		assert.Equal(t, "RunCommand", line.LoggerName)
+	}
+
+	assert.Equal(t, map[string]zapcore.Level{
+		"input": zapcore.DebugLevel,
+		"hello": zapcore.DebugLevel,
+		"oops":  zapcore.WarnLevel,
+	}, levels)
+	assert.Equal(t, 1, observed.FilterMessage("Command finished").Len())
+}
+
+func TestRunCommandExitCode(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+
+	result, err := tools.RunCommand(context.Background(), "sh", []string{"-c", "echo failure >&2; exit 3"},
+		tools.CommandOptions{Logger: zap.New(core)},
+	)
+	require.Error(t, err)
+
+	var exitError *exec.ExitError
+	require.ErrorAs(t, err, &exitError)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "failure\n", result.Stderr)
+	assert.Contains(t, tools.ErrorFields(err), tools.ErrorField{Key: "exit_code", Value: 3})
+
+	failed := observed.FilterMessage("Command failed").All()
+	require.Len(t, failed, 1)
+	assert.Equal(t, int64(3), failed[0].ContextMap()["exit_code"])
+
+	// the command is not started
+	result, err = tools.RunCommand(context.Background(), "/nonexistent/command", nil, tools.CommandOptions{})
+	require.Error(t, err)
+	assert.Equal(t, -1, result.ExitCode)
+}
+
+func TestRunCommandKillsProcessGroupOnTimeout(t *testing.T) {
+	start := time.Now()
+
+	// the background child keeps the output open, so waiting for it would take the whole sleep
+	result, err := tools.RunCommand(context.Background(), "sh", []string{"-c", "echo started; sleep 30 & sleep 30"},
+		tools.CommandOptions{Timeout: 200 * time.Millisecond},
+	)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Equal(t, -1, result.ExitCode)
+	assert.Equal(t, "started\n", result.Stdout)
+}
+
+func TestRunCommandCapsCapturedOutput(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+
+	result, err := tools.RunCommand(context.Background(), "sh", []string{"-c", "yes | head -n 1000; printf tail"},
+		tools.CommandOptions{MaxOutputSize: 100, Logger: zap.New(core)},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Repeat("y\n", 50), result.Stdout)
+	assert.True(t, result.StdoutTruncated)
+
+	// all lines are logged including the last one without a line break
+	lines := observed.FilterMessage("Command output").All()
+	require.Len(t, lines, 1001)
+	assert.Equal(t, "tail", lines[1000].ContextMap()["line"])
+}
@@ -0,0 +1,101 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func TestDisabledComponentsAreNotConnected(t *testing.T) {
+	logger := zap.NewNop()
+	ewc := tools.NewErrorWrapperCreator()
+	breakers := tools.NewCircuitBreakers(nil, logger)
+
+	clickHouse, cleanupClickHouse, err := utils.NewClickHouse(&utils.ClickHouseConfig{}, logger, ewc)
+	require.NoError(t, err)
+	defer cleanupClickHouse()
+
+	postgresql, cleanupPostgresql, err := utils.NewPostgresql(&utils.PostgresqlConfig{}, logger, ewc)
+	require.NoError(t, err)
+	defer cleanupPostgresql()
+
+	s3 := utils.NewS3(&utils.S3Config{}, logger, postgresql, ewc)
+	s3Manager, err := managers.NewS3Manager(&managers.S3ManagerConfig{}, logger, ewc, s3, breakers)
+	require.NoError(t, err)
+
+	statManager, err := managers.NewStatManager(logger, clickHouse, ewc, breakers)
+	require.NoError(t, err)
+
+	userAccountManager, err := managers.NewUserAccountManager(logger, postgresql, ewc, breakers)
+	require.NoError(t, err)
+
+	telegramBot := utils.NewTelegram(&utils.TelegramConfig{}, logger, ewc)
+	telegramBotManager, cleanupTelegram, err := managers.NewTelegramBotManager(
+		&managers.TelegramBotManagerConfig{}, logger, statManager, userAccountManager, telegramBot,
+		utils.NewTelegramLogSenderProxy(), ewc,
+	)
+	require.NoError(t, err)
+	defer cleanupTelegram()
+
+	assert.False(t, clickHouse.IsEnabled())
+	assert.False(t, postgresql.IsEnabled())
+	assert.False(t, s3.IsEnabled())
+	assert.False(t, telegramBot.IsEnabled())
+	assert.NotNil(t, s3Manager)
+
+	_, err = telegramBotManager.GetBot()
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+	assert.ErrorIs(t, telegramBotManager.AddCommonCommandsHandlers(&managers.CommonBotCommandsConfig{}), tools.ErrComponentDisabled)
+}
+
+func TestDisabledComponentsReturnError(t *testing.T) {
+	logger := zap.NewNop()
+	ewc := tools.NewErrorWrapperCreator()
+	breakers := tools.NewCircuitBreakers(nil, logger)
+
+	clickHouse, _, err := utils.NewClickHouse(&utils.ClickHouseConfig{}, logger, ewc)
+	require.NoError(t, err)
+
+	statManager, err := managers.NewStatManager(logger, clickHouse, ewc, breakers)
+	require.NoError(t, err)
+
+	err = statManager.AddSimple("event", "message")
+	require.ErrorIs(t, err, tools.ErrComponentDisabled)
+	assert.Contains(t, err.Error(), "clickhouse.is_enabled")
+
+	postgresql, _, err := utils.NewPostgresql(&utils.PostgresqlConfig{}, logger, ewc)
+	require.NoError(t, err)
+
+	_, err = postgresql.GetConnection()
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+
+	rabbitMQ := utils.NewRabbitMQ(&utils.RabbitMQConfig{}, logger, ewc)
+	_, err = rabbitMQ.GetConnection("")
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+
+	s3 := utils.NewS3(&utils.S3Config{}, logger, postgresql, ewc)
+	s3Manager, err := managers.NewS3Manager(&managers.S3ManagerConfig{}, logger, ewc, s3, breakers)
+	require.NoError(t, err)
+
+	_, err = s3Manager.ListObjects(managers.ListObjectsInput{})
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+
+	_, err = utils.NewTelegram(&utils.TelegramConfig{}, logger, ewc).CreateBot("token")
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+}
+
+func TestDisabledComponentsAreNotValidated(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.PostgresqlConfig{Port: -1}).Validate(v.Section("postgresql"))
+	(&managers.TelegramBotManagerConfig{}).Validate(v.Section("telegram"))
+	(&managers.S3ManagerConfig{}).Validate(v.Section("s3_manager"))
+
+	assert.NoError(t, v.Err())
+}
@@ -0,0 +1,264 @@
+package tests_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+type testEnvConfig struct {
+	Skipped  string `yaml:"-"`
+	Database struct {
+		Host     string        `yaml:"host"`
+		Port     int           `yaml:"port"`
+		Timeout  time.Duration `yaml:"timeout"`
+		IsEnable bool          `yaml:"is_enable"`
+	} `yaml:"database"`
+	Admins []int64 `yaml:"admins"`
+	Logger struct {
+		File struct {
+			MaxSize uint `yaml:"max_size"`
+		}
+		Overrides map[string]string `yaml:"overrides"`
+	} `yaml:"logger"`
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("TEST_SKIPPED", "skipped")
+	t.Setenv("TEST_DATABASE_HOST", "db.example.com")
+	t.Setenv("TEST_DATABASE_PORT", "6432")
+	t.Setenv("TEST_DATABASE_TIMEOUT", "5s")
+	t.Setenv("TEST_DATABASE_IS_ENABLE", "true")
+	t.Setenv("TEST_ADMINS", "1, 2,3")
+	t.Setenv("TEST_LOGGER_FILE_MAX_SIZE", "42")
+	t.Setenv("TEST_LOGGER_OVERRIDES", "ClickHouse=debug,S3Manager=warn")
+
+	cfg := testEnvConfig{}
+	cfg.Database.Host = "localhost"
+
+	overrides, err := tools.ApplyEnvOverrides("TEST", &cfg)
+	require.NoError(t, err)
+
+	assert.Empty(t, cfg.Skipped)
+	assert.Equal(t, "db.example.com", cfg.Database.Host)
+	assert.Equal(t, 6432, cfg.Database.Port)
+	assert.Equal(t, 5*time.Second, cfg.Database.Timeout)
+	assert.True(t, cfg.Database.IsEnable)
+	assert.Equal(t, []int64{1, 2, 3}, cfg.Admins)
+	assert.Equal(t, uint(42), cfg.Logger.File.MaxSize)
+	assert.Equal(t, map[string]string{"ClickHouse": "debug", "S3Manager": "warn"}, cfg.Logger.Overrides)
+
+	assert.Len(t, overrides, 7)
+	assert.Contains(t, overrides, tools.ConfigEnvOverride{
+		Path:    "logger.file.max_size",
+		EnvName: "TEST_LOGGER_FILE_MAX_SIZE",
+	})
+}
+
+func TestApplyEnvOverridesInvalidValue(t *testing.T) {
+	t.Setenv("TEST_DATABASE_PORT", "not a number")
+
+	cfg := testEnvConfig{}
+
+	_, err := tools.ApplyEnvOverrides("TEST", &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TEST_DATABASE_PORT")
+}
+
+func TestApplyEnvOverridesNotPointer(t *testing.T) {
+	_, err := tools.ApplyEnvOverrides("TEST", testEnvConfig{})
+	assert.ErrorIs(t, err, tools.ErrConfigNotStructPointer)
+}
+
+func TestConfigValidatorCollectsAllProblems(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.PostgresqlConfig{
+		IsEnabled: true,
+		Host:      "",
+		Port:      -1,
+		User:      "postgres",
+		Database:  "postgres",
+	}).Validate(v.Section("postgresql"))
+	(&managers.S3ManagerConfig{
+		IsEnabled: true,
+		Bucket:    "bucket",
+		MaxKeys:   1000,
+		Timeout:   10,
+	}).Validate(v.Section("s3_manager"))
+	(&utils.LoggerConfig{
+		Console: &utils.LoggerConsoleConfig{Level: "verbose"},
+	}).Validate(v.Section("logger"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+
+	var validationErrors tools.ConfigValidationErrors
+	require.ErrorAs(t, err, &validationErrors)
+
+	paths := []string{}
+	for _, validationError := range validationErrors {
+		paths = append(paths, validationError.Path)
+	}
+
+	assert.Equal(t, []string{"postgresql.host", "postgresql.port", "logger.console.level"}, paths)
+}
+
+func TestConfigValidatorNoProblems(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.RabbitMQConfig{
+		IsEnabled: true,
+		Host:      "localhost",
+		Port:      5672,
+		User:      "guest",
+	}).Validate(v.Section("rabbitmq"))
+
+	assert.NoError(t, v.Err())
+}
+
+func TestDiffConfig(t *testing.T) {
+	oldConfig := testEnvConfig{}
+	oldConfig.Database.Host = "localhost"
+	oldConfig.Admins = []int64{1}
+
+	newConfig := oldConfig
+	newConfig.Skipped = "skipped"
+	newConfig.Database.Port = 5432
+	newConfig.Admins = []int64{1, 2}
+
+	assert.Equal(t, []string{"database.port", "admins"}, tools.DiffConfig(&oldConfig, &newConfig))
+	assert.Empty(t, tools.DiffConfig(&oldConfig, &oldConfig))
+}
+
+func TestFileWatcherDetectsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.yaml")
+	changed := make(chan struct{}, 1)
+
+	watcher := tools.NewFileWatcher([]string{path}, 10*time.Millisecond, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go watcher.Run(ctx)
+
+	require.NoError(t, os.WriteFile(path, []byte("is_debug: true"), 0o600))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("change of the file was not detected")
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "main.yaml")
+	profilePath := filepath.Join(dir, "main-staging.yaml")
+
+	require.NoError(t, os.WriteFile(basePath, []byte("database:\n  host: base\n  port: 5432\n"), 0o600))
+	require.NoError(t, os.WriteFile(profilePath, []byte("database:\n  host: staging\n"), 0o600))
+	t.Setenv("TEST_DATABASE_PORT", "6432")
+
+	cfg := testEnvConfig{}
+	result, err := tools.LoadConfig([]tools.ConfigFile{
+		{Path: basePath},
+		{Path: profilePath, IsOptional: true},
+		{Path: filepath.Join(dir, "main-local.yaml"), IsOptional: true},
+	}, "TEST", &cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{basePath, profilePath}, result.Files)
+	assert.Equal(t, "staging", cfg.Database.Host)
+	assert.Equal(t, 6432, cfg.Database.Port)
+}
+
+func TestLoadConfigRequiredFileIsMissing(t *testing.T) {
+	cfg := testEnvConfig{}
+	_, err := tools.LoadConfig([]tools.ConfigFile{
+		{Path: filepath.Join(t.TempDir(), "main.yaml")},
+	}, "TEST", &cfg)
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+type testExplainConfig struct {
+	Database struct {
+		Host     string       `default:"localhost" yaml:"host"`
+		Port     int          `default:"5432"      yaml:"port"`
+		User     string       `yaml:"user"`
+		Password tools.Secret `yaml:"password"`
+		Name     string       `yaml:"name"`
+	} `yaml:"database"`
+}
+
+func TestExplainConfig(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "main.yaml")
+	localPath := filepath.Join(dir, "main-local.yaml")
+
+	require.NoError(t, os.WriteFile(basePath, []byte("database:\n  host: db\n  user: base\n"), 0o600))
+	require.NoError(t, os.WriteFile(localPath, []byte("database:\n  user: local\n"), 0o600))
+	t.Setenv("TEST_DATABASE_PASSWORD", "very-secret")
+
+	cfg := testExplainConfig{}
+	values, err := tools.ExplainConfig([]tools.ConfigFile{{Path: basePath}, {Path: localPath}}, "TEST", &cfg)
+	require.NoError(t, err)
+
+	sources := map[string]string{}
+	for _, value := range values {
+		sources[value.Path] = value.Source.String()
+	}
+
+	assert.Equal(t, map[string]string{
+		"database.host":     basePath + ":2",
+		"database.port":     "default",
+		"database.user":     localPath + ":2",
+		"database.password": "env TEST_DATABASE_PASSWORD",
+		"database.name":     "unset",
+	}, sources)
+
+	output := &strings.Builder{}
+	require.NoError(t, tools.WriteConfigExplanation(output, values))
+	assert.Contains(t, output.String(), "database:\n  host: db # "+basePath+":2\n")
+	assert.NotContains(t, output.String(), "very-secret")
+}
+
+func TestGenerateConfigJSONSchema(t *testing.T) {
+	schema, err := tools.GenerateConfigJSONSchema(testExplainConfig{}, tools.ConfigSchemaOptions{
+		Title: "test",
+		Enums: map[string][]string{"database.host": {"localhost", "db"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", schema["title"])
+
+	database, ok := schema["properties"].(map[string]interface{})["database"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, false, database["additionalProperties"])
+
+	properties, ok := database["properties"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"type":    "string",
+		"default": "localhost",
+		"enum":    []string{"localhost", "db"},
+	}, properties["host"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "default": 5432}, properties["port"])
+	assert.NotContains(t, properties["password"], "default")
+}
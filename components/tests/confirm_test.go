@@ -0,0 +1,93 @@
+package tests_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+var testDestructiveAction = tools.DestructiveAction{
+	Description:         "recreate tables",
+	Confirmation:        "app",
+	ConfirmationEnvName: "TEST_CONFIRM_RECREATE",
+}
+
+func TestConfirmDestructiveActionByEnv(t *testing.T) {
+	t.Setenv("TEST_CONFIRM_RECREATE", "app")
+
+	err := tools.ConfirmDestructiveActionWithInput(testDestructiveAction, false, strings.NewReader(""), false, io.Discard)
+	assert.NoError(t, err)
+}
+
+func TestConfirmDestructiveActionWrongEnv(t *testing.T) {
+	t.Setenv("TEST_CONFIRM_RECREATE", "other")
+
+	err := tools.ConfirmDestructiveActionWithInput(testDestructiveAction, false, strings.NewReader("app\n"), true, io.Discard)
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+}
+
+func TestConfirmDestructiveActionInteractive(t *testing.T) {
+	err := tools.ConfirmDestructiveActionWithInput(testDestructiveAction, false, strings.NewReader("app\n"), true, io.Discard)
+	assert.NoError(t, err)
+
+	err = tools.ConfirmDestructiveActionWithInput(testDestructiveAction, false, strings.NewReader("yes\n"), true, io.Discard)
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+}
+
+func TestConfirmDestructiveActionNotInteractive(t *testing.T) {
+	err := tools.ConfirmDestructiveActionWithInput(testDestructiveAction, false, strings.NewReader("app\n"), false, io.Discard)
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+}
+
+func TestConfirmDestructiveActionForbiddenInProduction(t *testing.T) {
+	t.Setenv("TEST_CONFIRM_RECREATE", "app")
+
+	err := tools.ConfirmDestructiveActionWithInput(testDestructiveAction, true, strings.NewReader("app\n"), true, io.Discard)
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionForbidden)
+}
+
+func TestConfirmDestructiveActionEmptyConfirmation(t *testing.T) {
+	action := testDestructiveAction
+	action.Confirmation = ""
+
+	t.Setenv("TEST_CONFIRM_RECREATE", "")
+
+	err := tools.ConfirmDestructiveActionWithInput(action, false, strings.NewReader("\n"), true, io.Discard)
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+	assert.ErrorContains(t, err, "confirmation value is empty")
+}
+
+func TestMigrateRefusesNotConfirmedRecreation(t *testing.T) {
+	logger := zap.NewNop()
+	ewc := tools.NewErrorWrapperCreator()
+
+	// databases are disabled, so Migrate fails on connection if recreation check is passed
+	clickHouse, _, err := utils.NewClickHouse(&utils.ClickHouseConfig{
+		AutoMigrate:      true,
+		IsNeedToRecreate: true,
+	}, logger, ewc)
+	require.NoError(t, err)
+
+	err = clickHouse.Migrate([]interface{}{})
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+
+	postgresql, _, err := utils.NewPostgresql(&utils.PostgresqlConfig{
+		AutoMigrate:      true,
+		IsNeedToRecreate: true,
+	}, logger, ewc)
+	require.NoError(t, err)
+
+	err = postgresql.Migrate([]interface{}{})
+	assert.ErrorIs(t, err, tools.ErrDestructiveActionNotConfirmed)
+
+	postgresql.Config.IsRecreateConfirmed = true
+	err = postgresql.Migrate([]interface{}{})
+	assert.ErrorIs(t, err, tools.ErrComponentDisabled)
+}
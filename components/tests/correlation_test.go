@@ -0,0 +1,119 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm/schema"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func TestEnsureCorrelationID(t *testing.T) {
+	assert.Empty(t, tools.CorrelationIDFromContext(context.Background()))
+
+	ctx, id := tools.EnsureCorrelationID(context.Background())
+	require.NotEmpty(t, id)
+	assert.Equal(t, id, tools.CorrelationIDFromContext(ctx))
+
+	// the ID of ctx is kept
+	same, sameID := tools.EnsureCorrelationID(ctx)
+	assert.Equal(t, ctx, same)
+	assert.Equal(t, id, sameID)
+
+	assert.Equal(t, id[:8], tools.ShortCorrelationID(id))
+	assert.Equal(t, "abc", tools.ShortCorrelationID("abc"))
+}
+
+func TestWrapMethodErrorContextAddsCorrelationIDOnce(t *testing.T) {
+	ctx := tools.WithCorrelationID(context.Background(), "0123456789")
+	ew := tools.NewErrorWrapperCreator().AppendToPrefix("Manager")
+
+	err := ew.GetContextMethodWrapper(ctx, "Outer")(ew.GetContextMethodWrapper(ctx, "Inner")(os.ErrNotExist))
+
+	assert.Equal(t, "Manager.Outer: Manager.Inner: file does not exist [correlation_id=0123456789]", err.Error())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "0123456789", tools.CorrelationIDFromError(err))
+
+	var correlatedError *tools.CorrelatedError
+	require.ErrorAs(t, err, &correlatedError)
+
+	assert.NoError(t, tools.WrapMethodErrorContext(ctx, nil, "Method"))
+
+	// the wrapper works as usual without ID
+	err = tools.WrapMethodErrorContext(context.Background(), os.ErrNotExist, "Method")
+	assert.Equal(t, "Method: file does not exist", err.Error())
+	assert.Empty(t, tools.CorrelationIDFromError(err))
+}
+
+func TestLoggerWithCorrelationID(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	tools.LoggerWithCorrelationID(context.Background(), logger).Info("without")
+	tools.LoggerWithCorrelationID(tools.WithCorrelationID(context.Background(), "id"), logger).Info("with")
+
+	assert.NotContains(t, observed.FilterMessage("without").All()[0].ContextMap(), tools.CorrelationIDLogKey)
+	assert.Equal(t, "id", observed.FilterMessage("with").All()[0].ContextMap()[tools.CorrelationIDLogKey])
+}
+
+func TestGormLoggerTrace(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	logger := utils.NewGormLogger(zap.New(core))
+	ctx := tools.WithCorrelationID(context.Background(), "id")
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	logger.Trace(ctx, time.Now(), query, nil)
+	assert.Equal(t, 0, observed.Len())
+
+	logger.Trace(ctx, time.Now(), query, errors.New("syntax error"))
+	logger.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+
+	failed := observed.FilterMessage("Query failed").All()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "SELECT 1", failed[0].ContextMap()["sql"])
+	assert.Equal(t, "id", failed[0].ContextMap()[tools.CorrelationIDLogKey])
+	assert.Equal(t, 1, observed.FilterMessage("Slow query").Len())
+}
+
+func TestTelegramErrorReply(t *testing.T) {
+	assert.Equal(t,
+		"Something went wrong. If you contact support, please specify error ID 01234567",
+		managers.TelegramErrorReply(errors.New("internal details"), "0123456789"),
+	)
+}
+
+func TestRabbitMQCorrelationID(t *testing.T) {
+	ctx := utils.RabbitMQDeliveryContext(context.Background(), amqp.Delivery{CorrelationId: "id"})
+	assert.Equal(t, "id", tools.CorrelationIDFromContext(ctx))
+
+	ctx = utils.RabbitMQDeliveryContext(context.Background(), amqp.Delivery{})
+	assert.NotEmpty(t, tools.CorrelationIDFromContext(ctx))
+
+	publishing := amqp.Publishing{}
+	utils.SetRabbitMQCorrelationID(tools.WithCorrelationID(context.Background(), "id"), &publishing)
+	assert.Equal(t, "id", publishing.CorrelationId)
+}
+
+func TestApplicationStatsCorrelationIDMigration(t *testing.T) {
+	statsSchema, err := schema.Parse(&managers.ApplicationStatsModel{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	// the migration must match names used by gorm
+	assert.Equal(t,
+		"ALTER TABLE "+statsSchema.Table+" ADD COLUMN IF NOT EXISTS "+statsSchema.LookUpField("CorrelationID").DBName+" String",
+		managers.ApplicationStatsCorrelationIDMigration,
+	)
+}
@@ -0,0 +1,206 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+var testDownloadContent = bytes.Repeat([]byte("0123456789"), 10000)
+
+func testDownloadChecksum() string {
+	sum := sha256.Sum256(testDownloadContent)
+	return hex.EncodeToString(sum[:])
+}
+
+// newTestDownloadServer serves testDownloadContent with support of Range requests.
+func newTestDownloadServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(testDownloadContent))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDownload(t *testing.T) {
+	server := newTestDownloadServer(t)
+	path := filepath.Join(t.TempDir(), "file")
+	progress := []tools.DownloadProgress{}
+
+	result, err := tools.Download(context.Background(), path, server.URL, tools.DownloadOptions{
+		SHA256:     testDownloadChecksum(),
+		MaxSize:    int64(len(testDownloadContent)),
+		OnProgress: func(p tools.DownloadProgress) { progress = append(progress, p) },
+		Logger:     zap.NewNop(),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &tools.DownloadResult{
+		Path:   path,
+		Size:   int64(len(testDownloadContent)),
+		SHA256: testDownloadChecksum(),
+	}, result)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, testDownloadContent, content)
+	assert.NoFileExists(t, path+tools.DownloadPartSuffix)
+
+	require.NotEmpty(t, progress)
+	assert.Equal(t, tools.DownloadProgress{
+		Downloaded: int64(len(testDownloadContent)),
+		Total:      int64(len(testDownloadContent)),
+	}, progress[len(progress)-1])
+}
+
+func TestDownloadWrongStatusKeepsTarget(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("previous"), 0o600))
+
+	_, err := tools.Download(context.Background(), path, server.URL, tools.DownloadOptions{})
+	require.ErrorIs(t, err, tools.ErrHTTPWrongStatus)
+	assert.Equal(t, "Download: wrong status: expected 200 got 404", err.Error())
+
+	var statusError *tools.HTTPStatusError
+	require.ErrorAs(t, err, &statusError)
+	assert.Equal(t, http.StatusNotFound, statusError.Got)
+	assert.Equal(t, tools.ErrorCategoryNotFound, tools.GetErrorCategory(err))
+	assert.Equal(t, "http.404", tools.GetErrorCode(err))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(content))
+	assert.NoFileExists(t, path+tools.DownloadPartSuffix)
+
+	// the old function reports the status too
+	err = tools.DownloadFileWithContext(context.Background(), path, server.URL, zap.NewNop())
+	require.ErrorIs(t, err, tools.ErrHTTPWrongStatus)
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	server := newTestDownloadServer(t)
+	path := filepath.Join(t.TempDir(), "file")
+
+	_, err := tools.Download(context.Background(), path, server.URL, tools.DownloadOptions{
+		SHA256: "0000",
+		Resume: true,
+	})
+	require.ErrorIs(t, err, tools.ErrDownloadChecksumMismatch)
+	assert.Equal(t, "download.checksum_mismatch", tools.GetErrorCode(err))
+
+	// the invalid file is not resumed
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+tools.DownloadPartSuffix)
+}
+
+func TestDownloadMaxSize(t *testing.T) {
+	server := newTestDownloadServer(t)
+	path := filepath.Join(t.TempDir(), "file")
+
+	_, err := tools.Download(context.Background(), path, server.URL, tools.DownloadOptions{MaxSize: 100})
+	require.ErrorIs(t, err, tools.ErrDownloadTooLarge)
+	assert.Contains(t, err.Error(), "100000 bytes, limit is 100")
+
+	// the size is checked while downloading if the server does not report it
+	chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for range 10 {
+			_, _ = w.Write(testDownloadContent[:1000])
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer chunked.Close()
+
+	_, err = tools.Download(context.Background(), path, chunked.URL, tools.DownloadOptions{MaxSize: 5000})
+	require.ErrorIs(t, err, tools.ErrDownloadTooLarge)
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+tools.DownloadPartSuffix)
+}
+
+func TestDownloadResumesInterruptedDownload(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// the connection is closed before the whole body is sent
+			w.Header().Set("Content-Length", strconv.Itoa(len(testDownloadContent)))
+			_, _ = w.Write(testDownloadContent[:30000])
+
+			return
+		}
+
+		assert.Equal(t, "bytes=30000-", r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(testDownloadContent))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	options := tools.DownloadOptions{SHA256: testDownloadChecksum(), Resume: true}
+
+	_, err := tools.Download(context.Background(), path, server.URL, options)
+	require.Error(t, err)
+	assert.True(t, tools.IsRetryableError(err))
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path+tools.DownloadPartSuffix)
+
+	result, err := tools.Download(context.Background(), path, server.URL, options)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30000), result.ResumedFrom)
+	assert.Equal(t, testDownloadChecksum(), result.SHA256)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, testDownloadContent, content)
+}
+
+func TestDownloadResumeEdgeCases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+
+	// the partial file is already complete, the server responds with 416
+	require.NoError(t, os.WriteFile(path+tools.DownloadPartSuffix, testDownloadContent, 0o600))
+
+	result, err := tools.Download(context.Background(), path, newTestDownloadServer(t).URL, tools.DownloadOptions{
+		SHA256: testDownloadChecksum(),
+		Resume: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testDownloadContent)), result.ResumedFrom)
+
+	// the server does not support ranges, the file is downloaded from the beginning
+	require.NoError(t, os.WriteFile(path+tools.DownloadPartSuffix, []byte("garbage"), 0o600))
+
+	withoutRanges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(testDownloadContent)
+	}))
+	defer withoutRanges.Close()
+
+	result, err = tools.Download(context.Background(), path, withoutRanges.URL, tools.DownloadOptions{
+		SHA256: testDownloadChecksum(),
+		Resume: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.ResumedFrom)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, testDownloadContent, content)
+}
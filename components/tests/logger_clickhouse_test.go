@@ -0,0 +1,215 @@
+package tests_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+var errClickHouseIsDown = errors.New("clickhouse is down")
+
+// testClickHouseLogWriter records written entries, fails if err is set and waits for unblock if it is set.
+type testClickHouseLogWriter struct {
+	mutex   sync.Mutex
+	entries []utils.ClickHouseLogEntry
+	batches int
+	err     error
+	unblock chan struct{}
+}
+
+func (w *testClickHouseLogWriter) WriteLogs(entries []utils.ClickHouseLogEntry) error {
+	if w.unblock != nil {
+		<-w.unblock
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	w.entries = append(w.entries, entries...)
+	w.batches++
+
+	return nil
+}
+
+func (w *testClickHouseLogWriter) getEntries() []utils.ClickHouseLogEntry {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return append([]utils.ClickHouseLogEntry{}, w.entries...)
+}
+
+func TestClickHouseLogSinkWritesBatches(t *testing.T) {
+	writer := &testClickHouseLogWriter{}
+	sink := utils.NewClickHouseLogSink(writer, nil, utils.ClickHouseLogSinkOptions{
+		FlushInterval: time.Hour,
+		BatchSize:     2,
+		BufferSize:    10,
+	})
+	defer sink.Close()
+
+	logger := zap.New(sink.Core(zapcore.InfoLevel), zap.AddCaller()).Named("StatManager")
+	logger.With(zap.String("event", "start")).Info("first", zap.Int("count", 3), zap.Strings("tags", []string{"a"}))
+	logger.Debug("skipped")
+	logger.Warn("second")
+	logger.Error("third")
+	require.NoError(t, logger.Sync())
+
+	entries := writer.getEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, 2, writer.batches)
+
+	assert.Equal(t, "info", entries[0].Level)
+	assert.Equal(t, "StatManager", entries[0].Logger)
+	assert.Equal(t, "first", entries[0].Message)
+	assert.Contains(t, entries[0].Caller, "tests/logger_clickhouse_test.go:")
+	assert.Equal(t, map[string]string{"event": "start", "count": "3", "tags": `["a"]`}, entries[0].Fields)
+	assert.False(t, entries[0].Timestamp.IsZero())
+	assert.Equal(t, "third", entries[2].Message)
+	assert.Zero(t, sink.Dropped())
+}
+
+func TestClickHouseLogSinkFallsBackWhenClickHouseIsDown(t *testing.T) {
+	writer := &testClickHouseLogWriter{err: errClickHouseIsDown}
+	fallback, observed := observer.New(zapcore.DebugLevel)
+	sink := utils.NewClickHouseLogSink(writer, fallback, utils.ClickHouseLogSinkOptions{
+		FlushInterval: 10 * time.Millisecond,
+		BatchSize:     100,
+		BufferSize:    100,
+	})
+	defer sink.Close()
+
+	logger := zap.New(sink.Core(zapcore.InfoLevel))
+	logger.Info("lost connection", zap.String("host", "ch"))
+
+	assert.Eventually(t, func() bool {
+		return observed.FilterMessage("lost connection").Len() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, observed.FilterMessage("Failed to write logs to ClickHouse, writing to fallback").Len())
+	assert.Equal(t, "ch", observed.FilterMessage("lost connection").All()[0].ContextMap()["host"])
+}
+
+func TestClickHouseLogSinkFallbackRespectsItsLevel(t *testing.T) {
+	writer := &testClickHouseLogWriter{err: errClickHouseIsDown}
+	fallback, observed := observer.New(zapcore.WarnLevel)
+	sink := utils.NewClickHouseLogSink(writer, fallback, utils.ClickHouseLogSinkOptions{
+		FlushInterval: time.Hour,
+		BatchSize:     100,
+		BufferSize:    100,
+	})
+	defer sink.Close()
+
+	logger := zap.New(sink.Core(zapcore.DebugLevel))
+	logger.Debug("debug entry")
+	logger.Error("error entry")
+	require.NoError(t, logger.Sync())
+
+	assert.Equal(t, 0, observed.FilterMessage("debug entry").Len())
+	assert.Equal(t, 1, observed.FilterMessage("error entry").Len())
+	assert.Equal(t, 1, observed.FilterMessage("Failed to write logs to ClickHouse, writing to fallback").Len())
+}
+
+func TestLoggerWritesEachEntryToFileOnceWhenClickHouseIsDown(t *testing.T) {
+	// nothing listens on the port after the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	portNumber, err := net.LookupPort("tcp", port)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	config := &utils.LoggerConfig{
+		File: &utils.LoggerFileConfig{Level: "info", Path: path},
+		ClickHouse: &utils.LoggerClickHouseConfig{
+			Level:         "debug",
+			Table:         "logs",
+			FlushInterval: time.Hour,
+			BatchSize:     100,
+			BufferSize:    100,
+			Connection:    &utils.ClickHouseConfig{IsEnabled: true, Host: host, Port: portNumber, Database: "default"},
+		},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	logger, cleanup, err := utils.NewLogger(config, levels, utils.NewTelegramLogSenderProxy())
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	logger.Debug("debug entry")
+	logger.Info("info entry")
+	logger.Error("error entry")
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// entries accepted by the file sink are not written again by the fallback, the rest are written by the fallback only
+	assert.Equal(t, 1, strings.Count(string(content), "debug entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "info entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "error entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "Failed to write logs to ClickHouse, writing to fallback"))
+}
+
+func TestClickHouseLogSinkDropsOnOverflow(t *testing.T) {
+	writer := &testClickHouseLogWriter{unblock: make(chan struct{})}
+	fallback, observed := observer.New(zapcore.DebugLevel)
+	sink := utils.NewClickHouseLogSink(writer, fallback, utils.ClickHouseLogSinkOptions{
+		FlushInterval: time.Hour,
+		BatchSize:     1,
+		BufferSize:    2,
+	})
+
+	logger := zap.New(sink.Core(zapcore.InfoLevel))
+
+	done := make(chan struct{})
+	go func() {
+		for range 100 {
+			logger.Info("message")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging is blocked by the sink")
+	}
+
+	assert.Positive(t, sink.Dropped())
+
+	close(writer.unblock)
+	sink.Close()
+
+	assert.Equal(t, uint64(100), sink.Dropped()+uint64(len(writer.getEntries())))
+	assert.Equal(t, 1, observed.FilterMessage("ClickHouse log entries dropped").Len())
+}
+
+func TestClickHouseLogWriterRejectsInvalidTable(t *testing.T) {
+	_, err := utils.NewClickHouseLogWriter(&utils.ClickHouseConfig{}, "logs; DROP TABLE users")
+	require.ErrorIs(t, err, utils.ErrInvalidClickHouseLogTable)
+
+	_, err = utils.NewClickHouseLogWriter(&utils.ClickHouseConfig{}, "monitoring.logs")
+	assert.NoError(t, err)
+}
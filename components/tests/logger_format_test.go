@@ -0,0 +1,134 @@
+package tests_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	encoderConfig := utils.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02")
+
+	buffer := &bytes.Buffer{}
+	logger := zap.New(zapcore.NewCore(utils.NewLogfmtEncoder(encoderConfig), zapcore.AddSync(buffer), zapcore.DebugLevel))
+
+	logger.Named("S3Manager").With(zap.String("bucket", "files")).Info(
+		"Bucket created",
+		zap.Int("count", 2),
+		zap.String("empty", ""),
+		zap.String("quote", `say "hi"`),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Duration("took", 1500*time.Millisecond),
+		zap.Namespace("request"),
+		zap.Bool("ok", true),
+	)
+
+	assert.Regexp(t,
+		`^ts=\d{4}-\d{2}-\d{2} level=info logger=S3Manager msg="Bucket created" bucket=files count=2 empty="" `+
+			`quote="say \\"hi\\"" tags="\[\\"a\\",\\"b\\"\]" took=1.5 request.ok=true\n$`,
+		buffer.String(),
+	)
+}
+
+func TestLoggerFormatsAndFileSinks(t *testing.T) {
+	dir := t.TempDir()
+	config := &utils.LoggerConfig{
+		File: &utils.LoggerFileConfig{Level: "info", Path: filepath.Join(dir, "app.log")},
+		Files: []*utils.LoggerFileConfig{{
+			Name:  "errors",
+			Level: "error",
+			Path:  filepath.Join(dir, "errors.log"),
+			Format: &utils.LoggerFormatConfig{
+				Encoder:         utils.LoggerEncoderLogfmt,
+				TimeFormat:      "epoch_millis",
+				CallerLevel:     utils.LoggerLevelNone,
+				StacktraceLevel: "error",
+			},
+		}},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	logger, _, err := utils.NewLogger(config, levels, utils.NewTelegramLogSenderProxy())
+	require.NoError(t, err)
+
+	logger.Info("info message")
+	logger.Error("error message", zap.Error(errors.New("failed")))
+	require.NoError(t, logger.Sync())
+
+	appLog, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+
+	errorsLog, err := os.ReadFile(filepath.Join(dir, "errors.log"))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(appLog), `"msg":"info message"`)
+	assert.Contains(t, string(appLog), `"caller":"tests/logger_format_test.go:`)
+	assert.NotContains(t, string(appLog), "stacktrace")
+
+	assert.NotContains(t, string(errorsLog), "info message")
+	assert.Regexp(t, `^ts=[\d.]+ level=error msg="error message" error=failed stacktrace=`, string(errorsLog))
+	assert.NotContains(t, string(errorsLog), "caller=")
+
+	require.NoError(t, levels.SetLevel(utils.LoggerFileSinkName("errors"), "info", 0))
+	assert.Equal(t, zapcore.InfoLevel, levels.Files["file.errors"].Level())
+	assert.Equal(t, []string{"file", "file.errors"}, sinkNames(levels.GetLevels()))
+}
+
+func sinkNames(levels []utils.LoggerSinkLevel) []string {
+	names := []string{}
+	for _, level := range levels {
+		names = append(names, level.Sink)
+	}
+
+	return names
+}
+
+func TestLoggerFormatsValidation(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.LoggerConfig{
+		Console: &utils.LoggerConsoleConfig{
+			Level:  "info",
+			Output: "stdnull",
+			Format: &utils.LoggerFormatConfig{Encoder: "xml", TimeFormat: "yesterday", StacktraceLevel: "never"},
+		},
+		Files: []*utils.LoggerFileConfig{
+			{Name: "errors", Level: "error", Path: "errors.log"},
+			{Name: "errors", Level: "warn", Path: "warnings.log"},
+			{Name: "Bad Name", Level: "info", Path: "other.log"},
+		},
+	}).Validate(v.Section("logger"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+
+	for _, path := range []string{
+		"logger.console.output",
+		"logger.console.format.encoder",
+		"logger.console.format.time_format",
+		"logger.console.format.stacktrace_level",
+		"logger.files[1].name",
+		"logger.files[2].name",
+	} {
+		assert.Contains(t, err.Error(), path)
+	}
+
+	assert.NotContains(t, err.Error(), "logger.files[0]")
+
+	_, err = utils.NewLoggerEncoder(&utils.LoggerFormatConfig{TimeFormat: "2006-01-02 15:04"}, utils.LoggerEncoderConsole)
+	assert.NoError(t, err)
+}
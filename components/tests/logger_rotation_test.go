@@ -0,0 +1,323 @@
+package tests_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+// testClock is a manually advanced clock for [utils.NewLogFile], [tools.NewCircuitBreaker]
+// and [utils.NewLoggerLevelsWithClock], timers created by AfterFunc are fired by Set.
+type testClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*testClockTimer
+}
+
+type testClockTimer struct {
+	at time.Time
+	f  func()
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Set changes the time and calls functions of expired timers in the calling goroutine.
+func (c *testClock) Set(now time.Time) {
+	c.mutex.Lock()
+
+	c.now = now
+	expired := []*testClockTimer{}
+	pending := []*testClockTimer{}
+
+	for _, timer := range c.timers {
+		if timer.at.After(now) {
+			pending = append(pending, timer)
+		} else {
+			expired = append(expired, timer)
+		}
+	}
+
+	c.timers = pending
+
+	c.mutex.Unlock()
+
+	for _, timer := range expired {
+		timer.f()
+	}
+}
+
+func (c *testClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	timer := &testClockTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+
+	return func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		isPending := slices.Contains(c.timers, timer)
+		c.timers = slices.DeleteFunc(c.timers, func(t *testClockTimer) bool { return t == timer })
+
+		return isPending
+	}
+}
+
+func newTestLogFile(t *testing.T, path string, config *utils.LoggerRotationConfig, clock *testClock) *utils.LogFile {
+	t.Helper()
+
+	file, err := utils.NewLogFile(path, config, clock.Now)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+
+	return file
+}
+
+func readDirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestLogFileDailyRotation(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+	file := newTestLogFile(t, filepath.Join(dir, "app.log"), &utils.LoggerRotationConfig{
+		Schedule: utils.LoggerRotationDaily,
+		MaxSize:  1,
+		Pattern:  "app-2006-01-02.log",
+	}, clock)
+
+	_, err := file.Write([]byte("first day\n"))
+	require.NoError(t, err)
+
+	clock.Set(time.Date(2024, 1, 2, 23, 59, 0, 0, time.UTC))
+	_, err = file.Write([]byte("still first day\n"))
+	require.NoError(t, err)
+
+	clock.Set(time.Date(2024, 1, 3, 0, 1, 0, 0, time.UTC))
+	_, err = file.Write([]byte("second day\n"))
+	require.NoError(t, err)
+
+	// size limit is combined with the schedule, names in the same period get an index
+	_, err = file.Write([]byte(strings.Repeat("x", 1024*1024-5)))
+	require.NoError(t, err)
+
+	_, err = file.Write([]byte("end\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"app-2024-01-02.log", "app-2024-01-03.log", "app.log"}, readDirNames(t, dir))
+
+	firstDay, err := os.ReadFile(filepath.Join(dir, "app-2024-01-02.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "first day\nstill first day\n", string(firstDay))
+
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+	assert.Len(t, current, 1024*1024-1)
+	assert.True(t, strings.HasSuffix(string(current), "xend\n"))
+
+	// a file left from a previous day is rotated on start
+	clock.Set(time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "app.log"), clock.Now().AddDate(0, 0, -1), clock.Now().AddDate(0, 0, -1)))
+	newTestLogFile(t, filepath.Join(dir, "app.log"), &utils.LoggerRotationConfig{
+		Schedule: utils.LoggerRotationDaily,
+		Pattern:  "app-2006-01-02.log",
+	}, clock)
+
+	assert.Equal(t, []string{"app-2024-01-02.log", "app-2024-01-03-1.log", "app-2024-01-03.log", "app.log"}, readDirNames(t, dir))
+}
+
+func TestLogFileKeepsWritingWhenRotationFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &testClock{now: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+	// names of rotated files are too long for the file system, so renaming fails
+	file := newTestLogFile(t, path, &utils.LoggerRotationConfig{
+		Schedule: utils.LoggerRotationDaily,
+		Pattern:  strings.Repeat("x", 300) + "-2006-01-02.log",
+	}, clock)
+
+	_, err := file.Write([]byte("before\n"))
+	require.NoError(t, err)
+	require.ErrorIs(t, file.Rotate(), syscall.ENAMETOOLONG)
+
+	_, err = file.Write([]byte("after failed rotate\n"))
+	require.NoError(t, err)
+
+	clock.Set(clock.Now().AddDate(0, 0, 1))
+	n, err := file.Write([]byte("next day\n"))
+	require.ErrorIs(t, err, syscall.ENAMETOOLONG)
+	assert.Equal(t, len("next day\n"), n)
+
+	// the failed rotation is not retried by each write
+	_, err = file.Write([]byte("before retry\n"))
+	require.NoError(t, err)
+
+	clock.Set(clock.Now().Add(time.Minute))
+	_, err = file.Write([]byte("after retry\n"))
+	require.ErrorIs(t, err, syscall.ENAMETOOLONG)
+	require.NoError(t, file.Close())
+
+	_, err = file.Write([]byte("after close\n"))
+	require.ErrorIs(t, err, os.ErrClosed)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "before\nafter failed rotate\nnext day\nbefore retry\nafter retry\n", string(content))
+	assert.Equal(t, []string{"app.log"}, readDirNames(t, dir))
+}
+
+func TestLogFileRotateAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+	file := newTestLogFile(t, filepath.Join(dir, "app.log"), &utils.LoggerRotationConfig{
+		MaxBackups: 2,
+		Compress:   true,
+	}, clock)
+
+	for i := range 4 {
+		_, err := file.Write([]byte("entry\n"))
+		require.NoError(t, err)
+
+		clock.Set(clock.Now().Add(time.Second))
+		require.NoError(t, file.Rotate())
+
+		// the mill compresses rotated files and keeps the newest ones
+		assert.Eventually(t, func() bool {
+			names := readDirNames(t, dir)
+			return len(names) == min(i+1, 2)+1 && strings.HasSuffix(names[len(names)-2], ".gz")
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	assert.Equal(t, []string{
+		"app-2024-01-02T10-00-02.000.log.gz",
+		"app-2024-01-02T10-00-03.000.log.gz",
+		"app.log",
+	}, readDirNames(t, dir))
+
+	// empty files are not rotated
+	require.NoError(t, file.Rotate())
+	assert.Len(t, readDirNames(t, dir), 3)
+}
+
+func TestLogFileRetentionByAgeAndTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	backups := map[string]time.Time{
+		"app-2024-01-01.log": now.AddDate(0, 0, -9),
+		"app-2024-01-07.log": now.AddDate(0, 0, -3),
+		"app-2024-01-08.log": now.AddDate(0, 0, -2),
+		"app-2024-01-09.log": now.AddDate(0, 0, -1),
+		"other.log":          now.AddDate(0, 0, -20),
+	}
+
+	for name, modTime := range backups {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 800*1024)), 0o644))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+
+	file := newTestLogFile(t, filepath.Join(dir, "app.log"), &utils.LoggerRotationConfig{
+		MaxAge:       5,
+		MaxTotalSize: 2,
+		Pattern:      "app-2006-01-02.log",
+	}, &testClock{now: now})
+
+	_, err := file.Write([]byte("entry\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Rotate())
+
+	// the oldest by age, then the oldest exceeding 2 MB, files not matching the pattern are kept
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{
+			"app-2024-01-08.log",
+			"app-2024-01-09.log",
+			"app-2024-01-10.log",
+			"app.log",
+			"other.log",
+		}, readDirNames(t, dir))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLoggerRotatesFilesOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	config := &utils.LoggerConfig{
+		File: &utils.LoggerFileConfig{
+			Level:    "info",
+			Path:     filepath.Join(dir, "app.log"),
+			Rotation: &utils.LoggerRotationConfig{},
+		},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	logger, cleanup, err := utils.NewLogger(config, levels, utils.NewTelegramLogSenderProxy())
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	logger.Info("before signal")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		return len(readDirNames(t, dir)) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	content, err := os.ReadFile(filepath.Join(dir, readDirNames(t, dir)[0]))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "before signal")
+}
+
+func TestLoggerRotationValidation(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.LoggerFileConfig{
+		Level: "info",
+		Path:  "app.log",
+		Rotation: &utils.LoggerRotationConfig{
+			MaxTotalSize: -1,
+			Schedule:     "weekly",
+			Pattern:      "app.log",
+		},
+	}).Validate(v.Section("file"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+
+	for _, path := range []string{"file.rotation.max_total_size", "file.rotation.schedule", "file.rotation.pattern"} {
+		assert.Contains(t, err.Error(), path)
+	}
+
+	_, err = utils.NewLogFile(filepath.Join(t.TempDir(), "app.log"), &utils.LoggerRotationConfig{Pattern: "logs/2006.log"}, nil)
+	require.ErrorIs(t, err, utils.ErrInvalidLogRotationPattern)
+}
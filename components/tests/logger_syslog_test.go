@@ -0,0 +1,285 @@
+package tests_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func newTestSyslogLogSink(
+	t *testing.T,
+	options utils.SyslogLogSinkOptions,
+	fallback zapcore.Core,
+) (*zap.Logger, *utils.SyslogLogSink) {
+	t.Helper()
+
+	if options.Facility == "" {
+		options.Facility = "local3"
+	}
+
+	options.AppName = "test-app"
+	options.Hostname = "test-host"
+	options.BufferSize = 100
+
+	sink, err := utils.NewSyslogLogSink(options, fallback)
+	require.NoError(t, err)
+	t.Cleanup(sink.Close)
+
+	core, err := sink.Core(zapcore.DebugLevel, &utils.LoggerFormatConfig{CallerLevel: utils.LoggerLevelNone})
+	require.NoError(t, err)
+
+	return zap.New(core, zap.AddCaller()), sink
+}
+
+func TestSyslogLogSinkUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	logger, sink := newTestSyslogLogSink(t, utils.SyslogLogSinkOptions{
+		Network: utils.SyslogNetworkUDP,
+		Address: listener.LocalAddr().String(),
+	}, nil)
+
+	logger.Named("S3Manager").Warn("Bucket is almost full", zap.Int("percent", 95))
+	require.NoError(t, sink.Sync())
+
+	buffer := make([]byte, 4096)
+	require.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := listener.ReadFrom(buffer)
+	require.NoError(t, err)
+
+	// local3 (19) * 8 + warning (4) = 156
+	assert.Regexp(t,
+		`^<156>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}\S+ test-host test-app `+strconv.Itoa(os.Getpid())+
+			` S3Manager - \{"level":"warn","ts":"[^"]+","logger":"S3Manager","msg":"Bucket is almost full","percent":95\}$`,
+		string(buffer[:n]),
+	)
+}
+
+func TestSyslogLogSinkTCPReconnects(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	fallback, observed := observer.New(zapcore.DebugLevel)
+	logger, sink := newTestSyslogLogSink(t, utils.SyslogLogSinkOptions{
+		Network:  utils.SyslogNetworkTCP,
+		Address:  listener.Addr().String(),
+		Facility: "user",
+	}, fallback)
+
+	messages := make(chan string, 10)
+	conns := make(chan net.Conn, 10)
+	go acceptSyslogConnections(listener, messages, conns)
+
+	logger.Error("first")
+	assert.Regexp(t, `^<11>1 .* - \{"level":"error".*"msg":"first"\}$`, receiveSyslogMessage(t, messages, time.Second))
+
+	// the server closes the connection, the sink notices it on writing and reconnects,
+	// an entry written to the closed connection before the reset may be lost as with any TCP syslog client
+	require.NoError(t, (<-conns).Close())
+
+	received := ""
+	for i := 0; i < 20 && !strings.Contains(received, "after reconnect"); i++ {
+		logger.Info("after reconnect")
+		require.NoError(t, sink.Sync())
+
+		received = receiveSyslogMessage(t, messages, 100*time.Millisecond)
+	}
+
+	assert.Contains(t, received, "after reconnect")
+	assert.Equal(t, 0, observed.FilterMessage("after reconnect").Len())
+}
+
+func TestSyslogLogSinkFallsBackWhenUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	fallback, observed := observer.New(zapcore.DebugLevel)
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	logger, sink := newTestSyslogLogSink(t, utils.SyslogLogSinkOptions{
+		Network:           utils.SyslogNetworkTCP,
+		Address:           address,
+		ReconnectInterval: time.Minute,
+		Now:               clock.Now,
+	}, fallback)
+
+	logger.Error("first")
+	logger.Error("second")
+	require.NoError(t, sink.Sync())
+
+	assert.Equal(t, 1, observed.FilterMessage("Failed to send log entry to syslog, writing to fallback").Len())
+	assert.Equal(t, 1, observed.FilterMessage("first").Len())
+	assert.Equal(t, 1, observed.FilterMessage("second").Len())
+
+	// the server is started again, the sink reconnects after the interval
+	listener, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	messages := make(chan string, 10)
+	go acceptSyslogConnections(listener, messages, nil)
+
+	// the interval is not passed yet
+	logger.Error("delayed")
+	require.NoError(t, sink.Sync())
+	assert.Equal(t, 1, observed.FilterMessage("delayed").Len())
+
+	clock.Set(clock.Now().Add(time.Minute))
+	logger.Error("third")
+
+	assert.Contains(t, receiveSyslogMessage(t, messages, time.Second), `"msg":"third"`)
+	require.NoError(t, sink.Sync())
+	assert.Equal(t, 1, observed.FilterMessage("Connection to syslog restored").Len())
+}
+
+func TestLoggerWritesEachEntryToFileOnceWhenSyslogIsUnavailable(t *testing.T) {
+	// nothing listens on the address after the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	config := &utils.LoggerConfig{
+		File: &utils.LoggerFileConfig{Level: "info", Path: path},
+		Syslog: &utils.LoggerSyslogConfig{
+			Level:             "debug",
+			Network:           utils.SyslogNetworkTCP,
+			Address:           address,
+			Facility:          "user",
+			BufferSize:        100,
+			ReconnectInterval: time.Minute,
+			Format:            &utils.LoggerFormatConfig{CallerLevel: utils.LoggerLevelNone},
+		},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	logger, cleanup, err := utils.NewLogger(config, levels, utils.NewTelegramLogSenderProxy())
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	logger.Debug("debug entry")
+	logger.Info("info entry")
+	logger.Error("error entry")
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// entries accepted by the file sink are not written again by the fallback, the rest are written by the fallback only
+	assert.Equal(t, 1, strings.Count(string(content), "debug entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "info entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "error entry"))
+	assert.Equal(t, 1, strings.Count(string(content), "Failed to send log entry to syslog, writing to fallback"))
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for level, severity := range map[zapcore.Level]int{
+		zapcore.DebugLevel:  7,
+		zapcore.InfoLevel:   6,
+		zapcore.WarnLevel:   4,
+		zapcore.ErrorLevel:  3,
+		zapcore.DPanicLevel: 2,
+		zapcore.PanicLevel:  1,
+		zapcore.FatalLevel:  0,
+	} {
+		assert.Equal(t, severity, utils.SyslogSeverity(level), level.String())
+	}
+}
+
+func TestLoggerSyslogValidation(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.LoggerSyslogConfig{
+		Level:    "info",
+		Network:  "http",
+		Facility: "local9",
+	}).Validate(v.Section("syslog"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+
+	for _, path := range []string{"syslog.network", "syslog.address", "syslog.facility", "syslog.buffer_size"} {
+		assert.Contains(t, err.Error(), path)
+	}
+
+	_, err = utils.NewSyslogLogSink(utils.SyslogLogSinkOptions{Network: "udp", Facility: "local9"}, nil)
+	require.ErrorIs(t, err, utils.ErrUnknownSyslogFacility)
+}
+
+// acceptSyslogConnections reads messages framed by octet counting until the listener is closed.
+// Accepted connections are sent to conns if it is not nil.
+func acceptSyslogConnections(listener net.Listener, messages chan<- string, conns chan<- net.Conn) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		if conns != nil {
+			conns <- conn
+		}
+
+		go func() {
+			reader := bufio.NewReader(conn)
+
+			for {
+				length, err := reader.ReadString(' ')
+				if err != nil {
+					return
+				}
+
+				size, err := strconv.Atoi(strings.TrimSpace(length))
+				if err != nil {
+					return
+				}
+
+				message := make([]byte, size)
+				if _, err := io.ReadFull(reader, message); err != nil {
+					return
+				}
+
+				messages <- string(message)
+			}
+		}()
+	}
+}
+
+var syslogFrameRegexp = regexp.MustCompile(`^<\d+>1 `)
+
+// receiveSyslogMessage returns a received message or an empty string after timeout.
+func receiveSyslogMessage(t *testing.T, messages <-chan string, timeout time.Duration) string {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		require.Regexp(t, syslogFrameRegexp, message)
+		return message
+	case <-time.After(timeout):
+		return ""
+	}
+}
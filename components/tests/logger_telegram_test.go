@@ -0,0 +1,235 @@
+package tests_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+var errTelegramIsDown = errors.New("telegram is down")
+
+// testTelegramLogSender records sent texts, fails if err is set and calls onSend before returning.
+type testTelegramLogSender struct {
+	mutex  sync.Mutex
+	texts  []string
+	err    error
+	onSend func()
+}
+
+func (s *testTelegramLogSender) SendLogAlert(text string) error {
+	if s.onSend != nil {
+		s.onSend()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.texts = append(s.texts, text)
+
+	return s.err
+}
+
+func (s *testTelegramLogSender) getTexts() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.texts...)
+}
+
+// newTestTelegramLogSink returns the sink driven by the clock and a function which makes it process
+// buffered entries and send summaries.
+func newTestTelegramLogSink(
+	t *testing.T,
+	sender utils.TelegramLogSender,
+	fallback zapcore.Core,
+	options utils.TelegramLogSinkOptions,
+) (*utils.TelegramLogSink, *testClock, func()) {
+	t.Helper()
+
+	if options.RateInterval == 0 {
+		options.RateInterval = time.Minute
+	}
+
+	if options.BufferSize == 0 {
+		options.BufferSize = 100
+	}
+
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ticks := make(chan time.Time)
+	options.Now = clock.Now
+	options.Ticks = ticks
+
+	sink := utils.NewTelegramLogSink(sender, fallback, options)
+	t.Cleanup(sink.Close)
+
+	// the second tick is received only after the first one is handled
+	tick := func() {
+		ticks <- clock.Now()
+		ticks <- clock.Now()
+	}
+
+	return sink, clock, tick
+}
+
+func TestTelegramLogSinkDeduplicates(t *testing.T) {
+	sender := &testTelegramLogSender{}
+	sink, clock, tick := newTestTelegramLogSink(t, sender, nil, utils.TelegramLogSinkOptions{
+		DedupWindow: time.Minute,
+		RateLimit:   10,
+	})
+
+	logger := zap.New(sink.Core(zapcore.ErrorLevel), zap.AddCaller()).Named("StatManager")
+	logger.Info("not forwarded")
+
+	for range 5 {
+		logger.Error("Failed to add stat", zap.String("event", "start"))
+	}
+
+	logger.Error("Other error")
+
+	tick()
+	require.Len(t, sender.getTexts(), 2)
+
+	// the summary is sent when the dedup window is finished
+	clock.Set(clock.Now().Add(time.Minute))
+	tick()
+
+	texts := sender.getTexts()
+	require.Len(t, texts, 3)
+	assert.True(t, strings.HasPrefix(texts[0], "ERROR StatManager: Failed to add stat\nat tests/logger_telegram_test.go:"))
+	assert.Contains(t, texts[0], "\n\nevent: start")
+	assert.Equal(t, "ERROR StatManager: Other error", strings.SplitN(texts[1], "\n", 2)[0])
+	assert.Equal(t, "4 similar errors suppressed:\nERROR StatManager: Failed to add stat", texts[2])
+
+	clock.Set(clock.Now().Add(time.Minute))
+	tick()
+	assert.Len(t, sender.getTexts(), 3)
+}
+
+func TestTelegramLogSinkRateLimit(t *testing.T) {
+	sender := &testTelegramLogSender{}
+	sink, _, tick := newTestTelegramLogSink(t, sender, nil, utils.TelegramLogSinkOptions{
+		DedupWindow: time.Minute,
+		RateLimit:   2,
+	})
+
+	logger := zap.New(sink.Core(zapcore.ErrorLevel))
+	for i := range 5 {
+		logger.Error("error " + string(rune('a'+i)))
+	}
+
+	tick()
+	assert.Len(t, sender.getTexts(), 2)
+}
+
+func TestTelegramLogSinkDoesNotRecurseOnFailure(t *testing.T) {
+	fallback, observed := observer.New(zapcore.DebugLevel)
+	sender := &testTelegramLogSender{err: errTelegramIsDown}
+	sink, clock, tick := newTestTelegramLogSink(t, sender, fallback, utils.TelegramLogSinkOptions{
+		DedupWindow: time.Second,
+		RateLimit:   100,
+		RetryAfter:  time.Minute,
+	})
+
+	logger := zap.New(sink.Core(zapcore.ErrorLevel))
+	// the worst case: sender logs its own failures to the same logger
+	sender.onSend = func() { logger.Error("Failed to send message", zap.Error(errTelegramIsDown)) }
+
+	logger.Error("first error")
+	tick()
+
+	clock.Set(clock.Now().Add(time.Second))
+	tick()
+
+	assert.Len(t, sender.getTexts(), 1)
+	require.Equal(t, 1, observed.Len())
+	assert.Equal(t, "Failed to send log entry to Telegram", observed.All()[0].Message)
+	assert.Equal(t, "telegram is down", observed.All()[0].ContextMap()["error"])
+}
+
+func TestLoggerTelegramLevelIsNotLoweredByOverrides(t *testing.T) {
+	config := &utils.LoggerConfig{
+		Telegram: &utils.LoggerTelegramConfig{
+			Level:       "error",
+			DedupWindow: time.Minute,
+			RateLimit:   10,
+			BufferSize:  10,
+		},
+		Overrides: map[string]string{"ClickHouse": "debug"},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	sender := &testTelegramLogSender{}
+	proxy := utils.NewTelegramLogSenderProxy()
+	proxy.SetSender(sender)
+
+	logger, cleanup, err := utils.NewLogger(config, levels, proxy)
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	logger.Named("ClickHouse").Debug("debug entry")
+	logger.Named("ClickHouse").Error("error entry")
+
+	// entries are sent in order, so the debug entry would be sent first
+	assert.Eventually(t, func() bool {
+		return len(sender.getTexts()) > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, strings.HasPrefix(sender.getTexts()[0], "ERROR ClickHouse: error entry"))
+}
+
+func TestTelegramLogSenderProxy(t *testing.T) {
+	proxy := utils.NewTelegramLogSenderProxy()
+	require.ErrorIs(t, proxy.SendLogAlert("text"), utils.ErrTelegramLogSenderNotSet)
+
+	sender := &testTelegramLogSender{}
+	proxy.SetSender(sender)
+	require.NoError(t, proxy.SendLogAlert("text"))
+	assert.Equal(t, []string{"text"}, sender.getTexts())
+
+	proxy.SetSender(nil)
+	require.ErrorIs(t, proxy.SendLogAlert("text"), utils.ErrTelegramLogSenderNotSet)
+}
+
+func TestFormatTelegramLogMessage(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		LoggerName: "S3Manager.PutObject",
+		Message:    "Failed to upload *file* <b>",
+		Stack:      "goroutine 1",
+	}
+
+	message := utils.FormatTelegramLogMessage(entry, map[string]interface{}{
+		"error":  "access denied",
+		"bucket": "files",
+		"size":   42,
+		"long":   strings.Repeat("x", 1000),
+	})
+
+	lines := strings.Split(message, "\n")
+	assert.Equal(t, "ERROR S3Manager.PutObject: Failed to upload *file* <b>", lines[0])
+	assert.Equal(t, "", lines[1])
+	assert.Equal(t, "bucket: files", lines[2])
+	assert.Equal(t, "error: access denied", lines[3])
+	assert.Len(t, []rune(strings.TrimPrefix(lines[4], "long: ")), 500)
+	assert.Equal(t, "size: 42", lines[5])
+	assert.NotContains(t, message, "goroutine")
+
+	huge := map[string]interface{}{}
+	for i := range 20 {
+		huge[strings.Repeat("k", i+1)] = strings.Repeat("v", 1000)
+	}
+
+	assert.Len(t, []rune(utils.FormatTelegramLogMessage(entry, huge)), 4096)
+}
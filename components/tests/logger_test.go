@@ -1,8 +1,21 @@
 package tests_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 )
 
@@ -16,3 +29,171 @@ func TestGetZapLoggerMustNotPanic(t *testing.T) {
 	logger := utils.GetZapLogger()
 	logger.Info("This is a test")
 }
+
+func TestParseZapLevel(t *testing.T) {
+	level, err := utils.ParseZapLevel("warn")
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.WarnLevel, level)
+
+	_, err = utils.ParseZapLevel("verbose")
+	assert.ErrorIs(t, err, utils.ErrUnknownLogLevel)
+}
+
+func newTestLoggerLevels(t *testing.T) *utils.LoggerLevels {
+	t.Helper()
+
+	levels, err := utils.NewLoggerLevels(&utils.LoggerConfig{
+		Console: &utils.LoggerConsoleConfig{Level: "info"},
+		File:    &utils.LoggerFileConfig{Level: "warn", Path: "app.log"},
+	})
+	require.NoError(t, err)
+
+	return levels
+}
+
+func TestLoggerLevelsSetLevel(t *testing.T) {
+	levels := newTestLoggerLevels(t)
+
+	require.NoError(t, levels.SetLevel(utils.LoggerSinkConsole, "debug", 0))
+	assert.Equal(t, zapcore.DebugLevel, levels.Console.Level())
+	assert.Equal(t, zapcore.WarnLevel, levels.File.Level())
+
+	assert.ErrorIs(t, levels.SetLevel("syslog", "debug", 0), utils.ErrUnknownLoggerSink)
+	assert.ErrorIs(t, levels.SetLevel(utils.LoggerSinkFile, "verbose", 0), utils.ErrUnknownLogLevel)
+
+	disabledFile, err := utils.NewLoggerLevels(&utils.LoggerConfig{Console: &utils.LoggerConsoleConfig{Level: "info"}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, disabledFile.SetLevel(utils.LoggerSinkFile, "debug", 0), utils.ErrUnknownLoggerSink)
+}
+
+func TestLoggerLevelsRevert(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	levels, err := utils.NewLoggerLevelsWithClock(&utils.LoggerConfig{
+		Console: &utils.LoggerConsoleConfig{Level: "info"},
+		File:    &utils.LoggerFileConfig{Level: "warn", Path: "app.log"},
+	}, clock.Now, clock.AfterFunc)
+	require.NoError(t, err)
+
+	require.NoError(t, levels.SetLevel(utils.LoggerSinkFile, "debug", time.Minute))
+	// the second temporary change keeps the original level to restore
+	require.NoError(t, levels.SetLevel(utils.LoggerSinkFile, "info", 2*time.Minute))
+	assert.Equal(t, zapcore.InfoLevel, levels.File.Level())
+	assert.Equal(t, clock.Now().Add(2*time.Minute), *levels.GetLevels()[1].RevertAt)
+
+	// the first restoration is cancelled
+	clock.Set(clock.Now().Add(time.Minute))
+	assert.Equal(t, zapcore.InfoLevel, levels.File.Level())
+
+	clock.Set(clock.Now().Add(time.Minute))
+	assert.Equal(t, zapcore.WarnLevel, levels.File.Level())
+	assert.Nil(t, levels.GetLevels()[1].RevertAt)
+
+	require.NoError(t, levels.SetLevel(utils.LoggerSinkConsole, "error", time.Minute))
+	// a permanent change cancels restoration
+	require.NoError(t, levels.SetLevel(utils.LoggerSinkConsole, "debug", 0))
+	clock.Set(clock.Now().Add(time.Hour))
+	assert.Equal(t, zapcore.DebugLevel, levels.Console.Level())
+}
+
+func TestLoggerLevelsHTTPHandler(t *testing.T) {
+	levels := newTestLoggerLevels(t)
+	server, cleanup, err := utils.NewAdminHTTPServer(
+		&utils.AdminHTTPConfig{Token: "admin-token"},
+		zap.NewNop(),
+		levels,
+		tools.NewErrorWrapperCreator(),
+		tools.NewCircuitBreakers(nil, zap.NewNop()),
+	)
+	require.NoError(t, err)
+	defer cleanup()
+
+	request := httptest.NewRequest(http.MethodGet, "/log-levels", nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodPost, "/log-levels",
+		strings.NewReader(`{"sink": "console", "level": "debug", "revert_after": "1m"}`),
+	)
+	request.Header.Set("Authorization", "Bearer admin-token")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"sink":"console","level":"debug","revert_at"`)
+	assert.Equal(t, zapcore.DebugLevel, levels.Console.Level())
+
+	request = httptest.NewRequest(http.MethodPost, "/log-levels", strings.NewReader(`{"sink": "file", "level": "loud"}`))
+	request.Header.Set("Authorization", "Bearer admin-token")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestTelegramLogLevelsCommandResponse(t *testing.T) {
+	levels := newTestLoggerLevels(t)
+
+	assert.Equal(t, "console: info\nfile: warn", managers.TelegramLogLevelsCommandResponse(levels, nil))
+	assert.Equal(t,
+		"Level of file is set to debug for 10m0s",
+		managers.TelegramLogLevelsCommandResponse(levels, []string{"file", "debug", "10m"}),
+	)
+	assert.Equal(t, zapcore.DebugLevel, levels.File.Level())
+	assert.Contains(t, managers.TelegramLogLevelsCommandResponse(levels, nil), "file: debug (until ")
+	assert.Contains(t,
+		managers.TelegramLogLevelsCommandResponse(levels, []string{"syslog", "debug"}),
+		"unknown logger sink",
+	)
+}
+
+func TestLoggerOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	config := &utils.LoggerConfig{
+		File: &utils.LoggerFileConfig{Level: "info", Path: path},
+		Overrides: map[string]string{
+			"ClickHouse":               "debug",
+			"ClickHouse.GetConnection": "error",
+			"TelegramBotManager":       "warn",
+		},
+	}
+
+	levels, err := utils.NewLoggerLevels(config)
+	require.NoError(t, err)
+
+	logger, _, err := utils.NewLogger(config, levels, utils.NewTelegramLogSenderProxy())
+	require.NoError(t, err)
+
+	logger.Named("ClickHouse").Debug("clickhouse debug")
+	logger.Named("ClickHouse").Named("GetConnection").Info("get connection info")
+	logger.Named("ClickHouseX").Debug("other debug")
+	logger.Named("TelegramBotManager").Info("bot info")
+	logger.Named("TelegramBotManager").Warn("bot warn")
+	logger.Named("S3Manager").Info("s3 info")
+	require.NoError(t, logger.Sync())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "clickhouse debug")
+	assert.NotContains(t, string(content), "get connection info")
+	assert.NotContains(t, string(content), "other debug")
+	assert.NotContains(t, string(content), "bot info")
+	assert.Contains(t, string(content), "bot warn")
+	assert.Contains(t, string(content), "s3 info")
+
+	require.NoError(t, levels.SetOverrides(map[string]string{"S3Manager": "error"}))
+	assert.Equal(t, map[string]string{"S3Manager": "error"}, levels.GetOverrides())
+	assert.ErrorIs(t, levels.SetOverrides(map[string]string{"S3Manager": "verbose"}), utils.ErrUnknownLogLevel)
+}
+
+func TestLoggerOverridesValidation(t *testing.T) {
+	v := tools.NewConfigValidator()
+
+	(&utils.LoggerConfig{Overrides: map[string]string{"ClickHouse": "loud"}}).Validate(v.Section("logger"))
+
+	err := v.Err()
+	require.ErrorIs(t, err, tools.ErrConfigInvalid)
+	assert.Contains(t, err.Error(), "logger.overrides.ClickHouse")
+
+	_, err = utils.NewLoggerLevels(&utils.LoggerConfig{Console: &utils.LoggerConsoleConfig{Level: "verbose"}})
+	assert.ErrorIs(t, err, utils.ErrUnknownLogLevel)
+}
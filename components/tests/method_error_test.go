@@ -0,0 +1,140 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+func openTestFile(path string) error {
+	ew := tools.NewErrorWrapperCreator().AppendToPrefix("Files").GetMethodWrapper("Open", "path", path)
+
+	_, err := os.Open(path)
+
+	return ew(err)
+}
+
+func TestMethodError(t *testing.T) {
+	ew := tools.NewErrorWrapperCreator().AppendToPrefix("Manager").GetMethodWrapper("Read", "attempt", 2)
+	err := ew(openTestFile("/nonexistent"))
+
+	assert.Equal(t, "Manager.Read: Files.Open: open /nonexistent: no such file or directory", err.Error())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	var pathError *os.PathError
+	require.ErrorAs(t, err, &pathError)
+
+	var methodError *tools.MethodError
+	require.ErrorAs(t, err, &methodError)
+	assert.Equal(t, "Manager", methodError.Component)
+	assert.Equal(t, "Read", methodError.Method)
+	assert.Equal(t, "Manager.Read", methodError.Name())
+
+	// the stack is captured only by the first wrap and starts at the place of the error
+	assert.Empty(t, methodError.Stack())
+
+	stack := tools.ErrorStack(err)
+	assert.True(t, strings.HasPrefix(stack, "github.com/roman-kart/go-initial-project/v2/components/tests_test.openTestFile\n"), stack)
+	assert.Contains(t, stack, "tests_test.TestMethodError")
+
+	assert.Equal(t, []tools.ErrorField{
+		{Key: "attempt", Value: 2},
+		{Key: "path", Value: "/nonexistent"},
+	}, tools.ErrorFields(err))
+
+	assert.NoError(t, ew(nil))
+}
+
+func TestWrapMethodErrorFields(t *testing.T) {
+	err := tools.WrapMethodError(os.ErrNotExist, "Method", "key", "value", 42, "odd")
+
+	assert.Equal(t, "Method: file does not exist", err.Error())
+	assert.Equal(t, []tools.ErrorField{
+		{Key: "key", Value: "value"},
+		{Key: "!BADKEY", Value: 42},
+		{Key: "!BADKEY", Value: "odd"},
+	}, tools.ErrorFields(err))
+
+	_, ok := tools.ErrorDetails(os.ErrNotExist)
+	assert.False(t, ok)
+}
+
+func TestMethodErrorMarshalLogObject(t *testing.T) {
+	ctx := tools.WithCorrelationID(context.Background(), "id")
+	err := tools.NewErrorWrapperCreator().AppendToPrefix("Manager").GetContextMethodWrapper(ctx, "Run")(
+		openTestFile("/nonexistent"),
+	)
+
+	details, ok := tools.ErrorDetails(err)
+	require.True(t, ok)
+
+	encoder := zapcore.NewMapObjectEncoder()
+	require.NoError(t, details.MarshalLogObject(encoder))
+
+	assert.Equal(t, "Manager", encoder.Fields["component"])
+	assert.Equal(t, "Run", encoder.Fields["method"])
+	assert.Equal(t, map[string]interface{}{"path": "/nonexistent"}, encoder.Fields["fields"])
+	assert.Equal(t, "id", encoder.Fields[tools.CorrelationIDLogKey])
+	assert.Contains(t, encoder.Fields["stack"], "tests_test.openTestFile")
+}
+
+func TestLoggerWritesErrorDetails(t *testing.T) {
+	logger, read := newTestRedactedLogger(t, nil)
+
+	err := tools.NewErrorWrapperCreator().AppendToPrefix("Manager").GetMethodWrapper("Login", "password", "qwerty")(
+		openTestFile("/nonexistent"),
+	)
+	logger.Error("Failed", zap.Error(err))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(read()), &entry))
+
+	assert.Equal(t, "Manager.Login: Files.Open: open /nonexistent: no such file or directory", entry["error"])
+
+	details, ok := entry["errorDetails"].(map[string]interface{})
+	require.True(t, ok, entry)
+	assert.Equal(t, "Login", details["method"])
+	assert.Equal(t, map[string]interface{}{"password": tools.SecretMask, "path": "/nonexistent"}, details["fields"])
+	assert.Contains(t, details["stack"], "tests_test.openTestFile")
+}
+
+func TestErrorDetailsCore(t *testing.T) {
+	err := openTestFile("/nonexistent")
+
+	core, observed := observer.New(zapcore.InfoLevel)
+	logger := zap.New(utils.NewErrorDetailsCore(core)).With(zap.NamedError("cause", err))
+	logger.Debug("skipped", zap.Error(err))
+	logger.Error("Failed", zap.Error(err), zap.String("user", "admin"))
+
+	require.Equal(t, 1, observed.Len())
+	fields := observed.All()[0].Context
+	keys := []string{}
+
+	for _, field := range fields {
+		keys = append(keys, field.Key)
+	}
+
+	assert.Equal(t, []string{"cause", "causeDetails", "error", "errorDetails", "user"}, keys)
+	assert.Equal(t, "Open", observed.All()[0].ContextMap()["errorDetails"].(map[string]interface{})["method"])
+
+	// a logger without the core writes only the message
+	plainCore, plainObserved := observer.New(zapcore.InfoLevel)
+	zap.New(plainCore).Error("Failed", zap.Error(err))
+	assert.NotContains(t, plainObserved.All()[0].ContextMap(), "errorDetails")
+
+	// the redactor only masks the message of the error
+	redactor, redactorErr := utils.NewLogRedactor(nil)
+	require.NoError(t, redactorErr)
+	assert.Len(t, redactor.RedactFields([]zapcore.Field{zap.Error(err)}), 1)
+}
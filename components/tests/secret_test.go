@@ -0,0 +1,64 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+type testSecretConfig struct {
+	Database struct {
+		Password tools.Secret `yaml:"password"`
+	} `yaml:"database"`
+	Token tools.Secret `yaml:"token"`
+	Plain string       `yaml:"plain"`
+}
+
+func TestSecretIsMasked(t *testing.T) {
+	cfg := testSecretConfig{}
+	cfg.Database.Password = "very-secret"
+	cfg.Token = "token"
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		assert.NotContains(t, fmt.Sprintf(format, cfg), "very-secret", format)
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "very-secret")
+
+	assert.Equal(t, "very-secret", cfg.Database.Password.Value())
+}
+
+func TestResolveSecrets(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "pg")
+	require.NoError(t, os.WriteFile(secretPath, []byte("from-file\n"), 0o600))
+	t.Setenv("TEST_TOKEN", "from-env")
+
+	cfg := testSecretConfig{}
+	cfg.Database.Password = tools.Secret("${file:" + secretPath + "}")
+	cfg.Token = "${env:TEST_TOKEN}"
+	cfg.Plain = "${env:TEST_TOKEN}"
+
+	require.NoError(t, tools.ResolveSecrets(&cfg))
+
+	assert.Equal(t, "from-file", cfg.Database.Password.Value())
+	assert.Equal(t, "from-env", cfg.Token.Value())
+	assert.Equal(t, "${env:TEST_TOKEN}", cfg.Plain, "only secrets are resolved")
+}
+
+func TestResolveSecretsInvalidReference(t *testing.T) {
+	cfg := testSecretConfig{}
+	cfg.Token = "${vault:secret/data}"
+
+	err := tools.ResolveSecrets(&cfg)
+	require.ErrorIs(t, err, tools.ErrSecretReference)
+	assert.Contains(t, err.Error(), "token")
+}
@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CircuitBreakerState is a state of [CircuitBreaker].
+type CircuitBreakerState string
+
+// States of [CircuitBreaker].
+const (
+	// CircuitBreakerClosed passes all calls, consecutive failures are counted.
+	CircuitBreakerClosed CircuitBreakerState = "closed"
+	// CircuitBreakerOpen rejects all calls with [ErrCircuitBreakerOpen] until the open timeout passes.
+	CircuitBreakerOpen CircuitBreakerState = "open"
+	// CircuitBreakerHalfOpen passes a limited number of trial calls, which decide whether the backend is recovered.
+	CircuitBreakerHalfOpen CircuitBreakerState = "half_open"
+)
+
+// ErrCircuitBreakerOpen is returned by [CircuitBreaker.Execute] without calling the operation
+// while the backend is considered unhealthy.
+var ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
+
+// errCircuitBreakerOperationPanicked is recorded as a failure when an operation panics.
+var errCircuitBreakerOperationPanicked = errors.New("operation panicked")
+
+// CircuitBreakerConfig contains thresholds of [CircuitBreaker].
+type CircuitBreakerConfig struct {
+	// FailureThreshold is a number of consecutive failures which opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is a duration of open state, then trial calls are allowed.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is a number of trial calls in half-open state,
+	// the breaker is closed after all of them succeed.
+	HalfOpenMaxCalls int
+}
+
+// Validate checks configuration values.
+func (c *CircuitBreakerConfig) Validate(v *ConfigValidator) {
+	v.Positive("failure_threshold", int64(c.FailureThreshold))
+	v.Positive("open_timeout", int64(c.OpenTimeout))
+	v.Positive("half_open_max_calls", int64(c.HalfOpenMaxCalls))
+}
+
+// CircuitBreakerStats is a state and counters of [CircuitBreaker] for monitoring.
+type CircuitBreakerStats struct {
+	Name                string              `json:"name"`
+	State               CircuitBreakerState `json:"state"`
+	ConsecutiveFailures int                 `json:"consecutive_failures"`
+	Successes           uint64              `json:"successes"`
+	Failures            uint64              `json:"failures"`
+	Rejected            uint64              `json:"rejected"`
+	StateChanges        uint64              `json:"state_changes"`
+	StateChangedAt      *time.Time          `json:"state_changed_at,omitempty"`
+}
+
+// CircuitBreaker makes calls to an unhealthy backend fail fast instead of waiting for timeouts.
+// After [CircuitBreakerConfig.FailureThreshold] consecutive failures the breaker opens and rejects calls,
+// after [CircuitBreakerConfig.OpenTimeout] it lets trial calls through and closes if they succeed.
+// Breaker without config passes all calls and only counts them.
+type CircuitBreaker struct {
+	name   string
+	config *CircuitBreakerConfig
+	logger *zap.Logger
+	now    func() time.Time
+
+	mutex               sync.Mutex
+	state               CircuitBreakerState
+	consecutiveFailures int
+	halfOpenCalls       int
+	halfOpenSuccesses   int
+	stateChangedAt      time.Time
+	successes           uint64
+	failures            uint64
+	rejected            uint64
+	stateChanges        uint64
+}
+
+// NewCircuitBreaker creates a closed [CircuitBreaker], name is shown in logs and stats, e.g. "clickhouse".
+// now is used instead of [time.Now] if it is not nil.
+func NewCircuitBreaker(
+	name string,
+	config *CircuitBreakerConfig,
+	logger *zap.Logger,
+	now func() time.Time,
+) *CircuitBreaker {
+	if now == nil {
+		now = time.Now
+	}
+
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+		logger: logger.With(zap.String("circuit_breaker", name)),
+		now:    now,
+		state:  CircuitBreakerClosed,
+	}
+}
+
+// Name returns the name of the breaker.
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, open breaker becomes half-open when the timeout passes.
+func (b *CircuitBreaker) State() CircuitBreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refreshState(context.Background())
+
+	return b.state
+}
+
+// Stats returns the state and counters of the breaker.
+func (b *CircuitBreaker) Stats() CircuitBreakerStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refreshState(context.Background())
+
+	stats := CircuitBreakerStats{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		Rejected:            b.rejected,
+		StateChanges:        b.stateChanges,
+	}
+
+	if !b.stateChangedAt.IsZero() {
+		stateChangedAt := b.stateChangedAt
+		stats.StateChangedAt = &stateChangedAt
+	}
+
+	return stats
+}
+
+// Execute calls operation if the breaker allows it and records the result.
+// Returns [ErrCircuitBreakerOpen] classified as retryable without calling operation if the breaker is open.
+// Errors caused by the request (not found, forbidden, invalid input) are answers of the backend,
+// so they are recorded as successes, see [IsCircuitBreakerFailure].
+// Cancellation, disabled components and rejections of other breakers say nothing about the backend,
+// so they are not recorded at all and free the trial call of the half-open breaker.
+// A panic of operation is recorded as a failure and is not recovered.
+func (b *CircuitBreaker) Execute(ctx context.Context, operation func(ctx context.Context) error) error {
+	if err := b.allow(ctx); err != nil {
+		return err
+	}
+
+	isPanicked := true
+
+	// otherwise a panicking trial call would keep the half-open breaker busy forever
+	defer func() {
+		if isPanicked {
+			b.record(ctx, errCircuitBreakerOperationPanicked)
+		}
+	}()
+
+	err := operation(ctx)
+	isPanicked = false
+
+	b.record(ctx, err)
+
+	return err
+}
+
+func (b *CircuitBreaker) allow(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refreshState(ctx)
+
+	switch {
+	case b.state == CircuitBreakerOpen,
+		b.state == CircuitBreakerHalfOpen && b.halfOpenCalls >= b.config.HalfOpenMaxCalls:
+		b.rejected++
+
+		return ClassifyError(
+			fmt.Errorf("%w: %s", ErrCircuitBreakerOpen, b.name),
+			ErrorCategoryRetryable,
+			"circuit_breaker.open",
+			"",
+		)
+	case b.state == CircuitBreakerHalfOpen:
+		b.halfOpenCalls++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err != nil && isCircuitBreakerNeutral(err) {
+		// another call may check the backend instead
+		if b.state == CircuitBreakerHalfOpen && b.halfOpenCalls > 0 {
+			b.halfOpenCalls--
+		}
+
+		return
+	}
+
+	if err == nil || !IsCircuitBreakerFailure(err) {
+		b.successes++
+		b.consecutiveFailures = 0
+
+		if b.state == CircuitBreakerHalfOpen {
+			b.halfOpenSuccesses++
+
+			if b.halfOpenSuccesses >= b.config.HalfOpenMaxCalls {
+				b.setState(ctx, CircuitBreakerClosed, err)
+			}
+		}
+
+		return
+	}
+
+	b.failures++
+	b.consecutiveFailures++
+
+	if b.config == nil {
+		return
+	}
+
+	if b.state == CircuitBreakerHalfOpen || b.consecutiveFailures >= b.config.FailureThreshold {
+		b.setState(ctx, CircuitBreakerOpen, err)
+	}
+}
+
+// refreshState moves open breaker to half-open state when the timeout passes.
+func (b *CircuitBreaker) refreshState(ctx context.Context) {
+	if b.state == CircuitBreakerOpen && b.now().Sub(b.stateChangedAt) >= b.config.OpenTimeout {
+		b.setState(ctx, CircuitBreakerHalfOpen, nil)
+	}
+}
+
+func (b *CircuitBreaker) setState(ctx context.Context, state CircuitBreakerState, err error) {
+	if b.state == state {
+		return
+	}
+
+	logger := LoggerWithCorrelationID(ctx, b.logger)
+	fields := []zap.Field{zap.String("from", string(b.state)), zap.String("to", string(state))}
+
+	switch state {
+	case CircuitBreakerOpen:
+		logger.Warn("Circuit breaker opened", append(fields,
+			zap.Int("consecutive_failures", b.consecutiveFailures),
+			zap.Duration("open_timeout", b.config.OpenTimeout),
+			zap.Error(err),
+		)...)
+	case CircuitBreakerHalfOpen:
+		logger.Info("Circuit breaker is half-open, trying calls", fields...)
+	case CircuitBreakerClosed:
+		logger.Info("Circuit breaker closed", fields...)
+	}
+
+	b.state = state
+	b.stateChangedAt = b.now()
+	b.stateChanges++
+	b.halfOpenCalls = 0
+	b.halfOpenSuccesses = 0
+
+	if state == CircuitBreakerClosed {
+		b.consecutiveFailures = 0
+	}
+}
+
+// IsCircuitBreakerFailure reports whether err means that the backend is unhealthy.
+func IsCircuitBreakerFailure(err error) bool {
+	switch GetErrorCategory(err) {
+	case ErrorCategoryNotFound, ErrorCategoryForbidden, ErrorCategoryInvalidInput:
+		return false
+	default:
+		return !isCircuitBreakerNeutral(err)
+	}
+}
+
+// isCircuitBreakerNeutral reports whether err says nothing about health of the backend,
+// i.e. the call was canceled by the caller or the backend was not called at all.
+func isCircuitBreakerNeutral(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrComponentDisabled) ||
+		errors.Is(err, ErrCircuitBreakerOpen)
+}
+
+// CircuitBreakers contains breakers of backends by name, so their states can be shown to admins.
+// Components sharing a backend share its breaker.
+type CircuitBreakers struct {
+	config   *CircuitBreakerConfig
+	logger   *zap.Logger
+	mutex    sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakers creates a registry of breakers with the same config, nil config disables breaking.
+// Using for configuring with wire.
+func NewCircuitBreakers(config *CircuitBreakerConfig, logger *zap.Logger) *CircuitBreakers {
+	return &CircuitBreakers{
+		config:   config,
+		logger:   logger.Named("CircuitBreaker"),
+		breakers: map[string]*CircuitBreaker{},
+	}
+}
+
+// Get returns the breaker of the backend, it is created on the first call.
+func (r *CircuitBreakers) Get(name string) *CircuitBreaker {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	breaker, ok := r.breakers[name]
+	if !ok {
+		breaker = NewCircuitBreaker(name, r.config, r.logger, nil)
+		r.breakers[name] = breaker
+	}
+
+	return breaker
+}
+
+// Stats returns stats of all breakers sorted by name.
+func (r *CircuitBreakers) Stats() []CircuitBreakerStats {
+	r.mutex.Lock()
+	names := SortMapKeys(r.breakers)
+	breakers := make([]*CircuitBreaker, 0, len(names))
+
+	for _, name := range names {
+		breakers = append(breakers, r.breakers[name])
+	}
+	r.mutex.Unlock()
+
+	stats := make([]CircuitBreakerStats, 0, len(breakers))
+	for _, breaker := range breakers {
+		stats = append(stats, breaker.Stats())
+	}
+
+	return stats
+}
@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultCommandMaxOutputSize limits captured stdout and stderr of [RunCommand]
+// if [CommandOptions.MaxOutputSize] is not set.
+const DefaultCommandMaxOutputSize = 1 << 20
+
+// commandMaxLineSize limits a line of output in logs, longer lines are split.
+const commandMaxLineSize = 64 << 10
+
+// commandWaitDelay limits waiting for output of processes which outlive the command.
+const commandWaitDelay = 5 * time.Second
+
+// CommandOptions describes how [RunCommand] starts a command and handles its output.
+type CommandOptions struct {
+	// Dir is a working directory, empty means the directory of the current process.
+	Dir string
+	// Env is added to the environment of the current process, e.g. "LANG=C".
+	Env []string
+	// Stdin is passed to the command, nil means no input.
+	Stdin io.Reader
+	// Timeout limits the duration of the command, 0 means it is limited only by the context.
+	Timeout time.Duration
+	// StdoutLevel and StderrLevel are levels of output lines in logs, default is info.
+	StdoutLevel zapcore.Level
+	StderrLevel zapcore.Level
+	// MaxOutputSize limits bytes of every stream in [CommandResult], default is [DefaultCommandMaxOutputSize].
+	// Lines beyond the limit are logged anyway.
+	MaxOutputSize int
+	// Logger receives output lines and the result, nil disables logging.
+	Logger *zap.Logger
+}
+
+// CommandResult is a result of [RunCommand].
+type CommandResult struct {
+	Command string
+	// ExitCode is -1 if the command was not started or was killed by a signal.
+	ExitCode        int
+	Duration        time.Duration
+	Stdout          string
+	Stderr          string
+	StdoutTruncated bool
+	StderrTruncated bool
+}
+
+// RunCommand runs the command and waits for it.
+// Stdout and stderr are logged line by line while the command runs and captured to the result.
+// When ctx is done or the timeout passes, the process group of the command is killed
+// (only the process on systems without process groups) and the error matches ctx.Err().
+// The result is returned even on error, the error of a non-zero exit code matches [*exec.ExitError].
+func RunCommand(ctx context.Context, name string, args []string, options CommandOptions) (*CommandResult, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = options.Dir
+	cmd.Stdin = options.Stdin
+	cmd.WaitDelay = commandWaitDelay
+
+	if len(options.Env) > 0 {
+		cmd.Env = append(os.Environ(), options.Env...)
+	}
+
+	setCommandProcessGroup(cmd)
+
+	logger := options.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	logger = LoggerWithCorrelationID(ctx, logger.Named("RunCommand")).With(zap.String("command", cmd.String()))
+	maxOutputSize := FirstNonEmpty(options.MaxOutputSize, DefaultCommandMaxOutputSize)
+
+	stdout := &commandOutputWriter{logger: logger, stream: "stdout", level: options.StdoutLevel, maxSize: maxOutputSize}
+	stderr := &commandOutputWriter{logger: logger, stream: "stderr", level: options.StderrLevel, maxSize: maxOutputSize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+
+	stdout.Flush()
+	stderr.Flush()
+
+	result := &CommandResult{
+		Command:         cmd.String(),
+		ExitCode:        -1,
+		Duration:        time.Since(start),
+		Stdout:          stdout.captured.String(),
+		Stderr:          stderr.captured.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+	}
+
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+
+		logger.Error("Command failed",
+			zap.Int("exit_code", result.ExitCode),
+			zap.Duration("duration", result.Duration),
+			zap.Error(err),
+		)
+
+		return result, WrapMethodErrorContext(ctx, err, "RunCommand",
+			"command", result.Command,
+			"exit_code", result.ExitCode,
+		)
+	}
+
+	logger.Info("Command finished", zap.Int("exit_code", result.ExitCode), zap.Duration("duration", result.Duration))
+
+	return result, nil
+}
+
+// commandOutputWriter logs complete lines of a stream and captures up to maxSize bytes of it.
+type commandOutputWriter struct {
+	logger    *zap.Logger
+	stream    string
+	level     zapcore.Level
+	maxSize   int
+	captured  bytes.Buffer
+	truncated bool
+	line      []byte
+}
+
+func (w *commandOutputWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	w.line = append(w.line, p...)
+
+	logged := 0
+
+	for {
+		end := bytes.IndexByte(w.line[logged:], '\n')
+		if end < 0 {
+			break
+		}
+
+		w.log(w.line[logged : logged+end])
+		logged += end + 1
+	}
+
+	for len(w.line)-logged >= commandMaxLineSize {
+		w.log(w.line[logged : logged+commandMaxLineSize])
+		logged += commandMaxLineSize
+	}
+
+	w.line = append(w.line[:0], w.line[logged:]...)
+
+	return len(p), nil
+}
+
+// Flush logs the last line without a line break.
+func (w *commandOutputWriter) Flush() {
+	if len(w.line) > 0 {
+		w.log(w.line)
+		w.line = w.line[:0]
+	}
+}
+
+func (w *commandOutputWriter) capture(p []byte) {
+	remaining := w.maxSize - w.captured.Len()
+	if len(p) > remaining {
+		p = p[:max(remaining, 0)]
+		w.truncated = true
+	}
+
+	w.captured.Write(p)
+}
+
+func (w *commandOutputWriter) log(line []byte) {
+	if entry := w.logger.Check(w.level, "Command output"); entry != nil {
+		entry.Write(zap.String("stream", w.stream), zap.String("line", string(bytes.TrimSuffix(line, []byte("\r")))))
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tools
+
+import "os/exec"
+
+// setCommandProcessGroup keeps the default cancellation, only the process of the command is killed.
+func setCommandProcessGroup(*exec.Cmd) {}
@@ -0,0 +1,384 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/configor"
+)
+
+// ErrConfigNotStructPointer is returned when a configuration object is not a pointer to a struct.
+var ErrConfigNotStructPointer = errors.New("config must be a pointer to a struct")
+
+// ErrConfigUnsupportedType is returned when a configuration field has a type that cannot be set from a string.
+var ErrConfigUnsupportedType = errors.New("unsupported config field type")
+
+// ConfigEnvOverride describes a configuration value that was taken from an environment variable.
+type ConfigEnvOverride struct {
+	// Path is a dot-separated YAML path of the field, e.g. "postgresql.password".
+	Path string
+	// EnvName is a name of the environment variable, e.g. "APP_POSTGRESQL_PASSWORD".
+	EnvName string
+}
+
+// ConfigFile is a config file (layer) loaded by [LoadConfig].
+type ConfigFile struct {
+	Path string
+	// IsOptional files are skipped if they do not exist.
+	IsOptional bool
+}
+
+// ConfigLoadResult contains information about how a configuration was loaded.
+type ConfigLoadResult struct {
+	// Files which were loaded, in order of loading.
+	Files []string
+	// EnvOverrides contains fields whose values were taken from environment variables.
+	EnvOverrides []ConfigEnvOverride
+}
+
+// LoadConfig loads configuration from files to obj and applies environment overrides after that.
+// References in [Secret] fields are resolved at the end (see [ResolveSecrets]).
+//
+// Merge order: default tags, then files in the given order (later files override earlier ones),
+// then environment variables.
+//
+// Accepts:
+//   - files - config files in order of loading, optional files are skipped if absent
+//   - envPrefix - prefix of environment variables (see [ApplyEnvOverrides]), empty string disables overrides
+//   - obj - pointer to config struct
+//
+// Returns:
+//   - *[ConfigLoadResult] - loaded files and environment overrides
+//   - error - nil or error if any error occurred
+func LoadConfig(files []ConfigFile, envPrefix string, obj interface{}) (*ConfigLoadResult, error) {
+	ew := GetErrorWrapper("LoadConfig")
+	configPaths := []string{}
+
+	for _, file := range files {
+		if _, err := os.Stat(file.Path); err != nil {
+			if os.IsNotExist(err) && file.IsOptional {
+				continue
+			}
+
+			if os.IsNotExist(err) {
+				fmt.Printf("Config file not found: %s\n", file.Path)
+			} else {
+				fmt.Printf("Skip file because an error occurred while checking the file: %s\n", file.Path)
+			}
+
+			return nil, ew(err)
+		}
+
+		configPaths = append(configPaths, file.Path)
+	}
+
+	// configor gives higher priority to earlier files
+	err := configor.Load(obj, reverseStrings(configPaths)...)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	result := &ConfigLoadResult{
+		Files:        configPaths,
+		EnvOverrides: []ConfigEnvOverride{},
+	}
+
+	if envPrefix != "" {
+		result.EnvOverrides, err = ApplyEnvOverrides(envPrefix, obj)
+		if err != nil {
+			return nil, ew(err)
+		}
+	}
+
+	err = ResolveSecrets(obj)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return result, nil
+}
+
+// ApplyEnvOverrides sets fields of obj from environment variables.
+//
+// Name of the variable is built from prefix and YAML names of the field and its parents,
+// joined with underscore and converted to upper case:
+// field `yaml:"max_size"` of Logger.File.Rotation with prefix "APP" is read from APP_LOGGER_FILE_ROTATION_MAX_SIZE.
+// Fields with `yaml:"-"` are skipped.
+// Values are parsed by [SetConfigValueFromString].
+//
+// Returns list of the overridden fields.
+func ApplyEnvOverrides(prefix string, obj interface{}) ([]ConfigEnvOverride, error) {
+	ew := GetErrorWrapper("ApplyEnvOverrides")
+
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil, ew(ErrConfigNotStructPointer)
+	}
+
+	overrides := []ConfigEnvOverride{}
+
+	err := applyEnvOverrides(value.Elem(), "", prefix, &overrides)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return overrides, nil
+}
+
+func applyEnvOverrides(value reflect.Value, path string, envName string, overrides *[]ConfigEnvOverride) error {
+	valueType := value.Type()
+
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+
+		name, ok := ConfigFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinNotEmpty(".", path, name)
+		fieldEnvName := strings.ToUpper(joinNotEmpty("_", envName, name))
+		fieldValue := value.Field(i)
+
+		if isConfigSection(fieldValue.Type()) {
+			err := applyEnvOverrides(fieldValue, fieldPath, fieldEnvName, overrides)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		raw, found := os.LookupEnv(fieldEnvName)
+		if !found {
+			continue
+		}
+
+		if err := SetConfigValueFromString(fieldValue, raw); err != nil {
+			return fmt.Errorf("%s: %w", fieldEnvName, err)
+		}
+
+		*overrides = append(*overrides, ConfigEnvOverride{
+			Path:    fieldPath,
+			EnvName: fieldEnvName,
+		})
+	}
+
+	return nil
+}
+
+// ConfigFieldName returns a name of the field in YAML config.
+// Name is taken from `yaml` tag or, if the tag is absent, is the lowercased field name (like gopkg.in/yaml.v3 does).
+// Returns false if the field is unexported or skipped with `yaml:"-"`.
+func ConfigFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, true
+}
+
+// isConfigSection reports whether the type is a nested configuration section (not a leaf value).
+func isConfigSection(valueType reflect.Type) bool {
+	return valueType.Kind() == reflect.Struct && valueType != reflect.TypeOf(time.Time{})
+}
+
+// SetConfigValueFromString parses raw and sets the result to value.
+//
+// Supported types:
+//   - string, bool, integers, unsigned integers, floats
+//   - [time.Duration] in format of [time.ParseDuration]
+//   - slices of the types above - comma-separated list, e.g. "1,2,3"
+//   - maps with string keys - comma-separated key=value pairs, e.g. "ClickHouse=debug,S3Manager=warn"
+//   - pointers to the types above
+func SetConfigValueFromString(value reflect.Value, raw string) error {
+	ew := GetErrorWrapper("SetConfigValueFromString")
+
+	//nolint:exhaustive
+	switch value.Kind() {
+	case reflect.Pointer:
+		newValue := reflect.New(value.Type().Elem())
+		if err := SetConfigValueFromString(newValue.Elem(), raw); err != nil {
+			return err
+		}
+
+		value.Set(newValue)
+	case reflect.Slice:
+		items := splitConfigList(raw)
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+
+		for i, item := range items {
+			if err := SetConfigValueFromString(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+
+		value.Set(slice)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return ew(fmt.Errorf("%w: %s", ErrConfigUnsupportedType, value.Type()))
+		}
+
+		items := splitConfigList(raw)
+		m := reflect.MakeMapWithSize(value.Type(), len(items))
+
+		for _, item := range items {
+			key, itemValue, found := strings.Cut(item, "=")
+			if !found {
+				return ew(fmt.Errorf("%w: expected key=value, got %q", strconv.ErrSyntax, item))
+			}
+
+			mapValue := reflect.New(value.Type().Elem()).Elem()
+			if err := SetConfigValueFromString(mapValue, strings.TrimSpace(itemValue)); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(value.Type().Key()), mapValue)
+		}
+
+		value.Set(m)
+	default:
+		return ew(setConfigScalarFromString(value, raw))
+	}
+
+	return nil
+}
+
+func setConfigScalarFromString(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(d))
+
+		return nil
+	}
+
+	//nolint:exhaustive
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("%w: %s", ErrConfigUnsupportedType, value.Type())
+	}
+
+	return nil
+}
+
+// splitConfigList splits comma-separated list, trims spaces and skips empty items.
+// Enclosing brackets are allowed: "[1, 2]" is the same as "1,2".
+func splitConfigList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "[")
+	raw = strings.TrimSuffix(raw, "]")
+
+	items := []string{}
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func joinNotEmpty(separator string, parts ...string) string {
+	notEmpty := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if part != "" {
+			notEmpty = append(notEmpty, part)
+		}
+	}
+
+	return strings.Join(notEmpty, separator)
+}
+
+func reverseStrings(values []string) []string {
+	reversed := make([]string, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		reversed = append(reversed, values[i])
+	}
+
+	return reversed
+}
+
+// DiffConfig compares two configs of the same type and returns YAML paths of changed values.
+// Slices and maps are compared as a whole, so path of a changed list is a path of the list itself.
+func DiffConfig(oldConfig interface{}, newConfig interface{}) []string {
+	changed := []string{}
+	diffConfig(reflect.Indirect(reflect.ValueOf(oldConfig)), reflect.Indirect(reflect.ValueOf(newConfig)), "", &changed)
+
+	return changed
+}
+
+func diffConfig(oldValue reflect.Value, newValue reflect.Value, path string, changed *[]string) {
+	valueType := oldValue.Type()
+
+	for i := range valueType.NumField() {
+		name, ok := ConfigFieldName(valueType.Field(i))
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinNotEmpty(".", path, name)
+		oldField := oldValue.Field(i)
+		newField := newValue.Field(i)
+
+		if isConfigSection(oldField.Type()) {
+			diffConfig(oldField, newField, fieldPath, changed)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldField.Interface(), newField.Interface()) {
+			*changed = append(*changed, fieldPath)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of [ConfigValueSource].
+const (
+	ConfigSourceDefault = "default"
+	ConfigSourceFile    = "file"
+	ConfigSourceEnv     = "env"
+	ConfigSourceUnset   = "unset"
+)
+
+// ConfigValueSource describes where a configuration value came from.
+type ConfigValueSource struct {
+	// Kind is one of ConfigSource* constants.
+	Kind    string `json:"kind"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	EnvName string `json:"env_name,omitempty"`
+}
+
+func (s ConfigValueSource) String() string {
+	switch s.Kind {
+	case ConfigSourceFile:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	case ConfigSourceEnv:
+		return "env " + s.EnvName
+	default:
+		return s.Kind
+	}
+}
+
+// ConfigValue is an effective configuration value with its source.
+type ConfigValue struct {
+	// Path is a dot-separated YAML path of the value, e.g. "postgresql.port".
+	Path string `json:"path"`
+	// Value is masked for [Secret] fields.
+	Value  interface{}       `json:"value"`
+	Source ConfigValueSource `json:"source"`
+}
+
+// ExplainConfig loads configuration like [LoadConfig] and returns every leaf value with its source.
+// Values are returned in order of struct fields.
+//
+// Source is detected with priority: environment variable, the last file which contains the path,
+// `default` tag, unset.
+func ExplainConfig(files []ConfigFile, envPrefix string, obj interface{}) ([]ConfigValue, error) {
+	ew := GetErrorWrapper("ExplainConfig")
+
+	result, err := LoadConfig(files, envPrefix, obj)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	sources := map[string]ConfigValueSource{}
+
+	for _, path := range result.Files {
+		if err := collectConfigFileLocations(path, sources); err != nil {
+			return nil, ew(err)
+		}
+	}
+
+	for _, override := range result.EnvOverrides {
+		sources[override.Path] = ConfigValueSource{Kind: ConfigSourceEnv, EnvName: override.EnvName}
+	}
+
+	values := []ConfigValue{}
+	explainConfig(reflect.Indirect(reflect.ValueOf(obj)), "", sources, &values)
+
+	return values, nil
+}
+
+func explainConfig(value reflect.Value, path string, sources map[string]ConfigValueSource, values *[]ConfigValue) {
+	valueType := value.Type()
+
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+
+		name, ok := ConfigFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinNotEmpty(".", path, name)
+		fieldValue := value.Field(i)
+
+		if isConfigSection(fieldValue.Type()) {
+			explainConfig(fieldValue, fieldPath, sources, values)
+			continue
+		}
+
+		source, found := sources[fieldPath]
+		if !found {
+			source = ConfigValueSource{Kind: ConfigSourceUnset}
+			if _, hasDefault := field.Tag.Lookup("default"); hasDefault {
+				source.Kind = ConfigSourceDefault
+			}
+		}
+
+		*values = append(*values, ConfigValue{
+			Path:   fieldPath,
+			Value:  fieldValue.Interface(),
+			Source: source,
+		})
+	}
+}
+
+// collectConfigFileLocations adds locations of all values of YAML file to sources.
+func collectConfigFileLocations(path string, sources map[string]ConfigValueSource) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	collectYAMLNodeLocations(document.Content[0], "", path, sources)
+
+	return nil
+}
+
+func collectYAMLNodeLocations(node *yaml.Node, path string, file string, sources map[string]ConfigValueSource) {
+	if node.Kind != yaml.MappingNode {
+		sources[path] = ConfigValueSource{Kind: ConfigSourceFile, File: file, Line: node.Line}
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		collectYAMLNodeLocations(node.Content[i+1], joinNotEmpty(".", path, key.Value), file, sources)
+	}
+}
+
+// WriteConfigExplanation writes values as YAML-like tree with sources in comments:
+//
+//	postgresql:
+//	  host: localhost # config/main.yaml:15
+//	  password: *** # env APP_POSTGRESQL_PASSWORD
+func WriteConfigExplanation(w io.Writer, values []ConfigValue) error {
+	sections := []string{}
+
+	for _, value := range values {
+		segments := strings.Split(value.Path, ".")
+		valueSections := segments[:len(segments)-1]
+
+		common := 0
+		for common < len(sections) && common < len(valueSections) && sections[common] == valueSections[common] {
+			common++
+		}
+
+		for depth := common; depth < len(valueSections); depth++ {
+			if _, err := fmt.Fprintf(w, "%s%s:\n", strings.Repeat("  ", depth), valueSections[depth]); err != nil {
+				return err
+			}
+		}
+
+		sections = valueSections
+
+		_, err := fmt.Fprintf(w, "%s%s: %v # %s\n",
+			strings.Repeat("  ", len(valueSections)),
+			segments[len(segments)-1],
+			value.Value,
+			value.Source,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSchemaOptions contains additional information for [GenerateConfigJSONSchema].
+type ConfigSchemaOptions struct {
+	// Title of the schema.
+	Title string
+	// Enums contains allowed values by YAML path of the value, e.g. "logger.console.level".
+	// Items of lists and values of maps have path of the list or map with ".*" suffix.
+	Enums map[string][]string
+}
+
+// GenerateConfigJSONSchema generates JSON Schema (draft 2020-12) for config struct.
+//
+// Names of properties are taken from `yaml` tags (see [ConfigFieldName]),
+// defaults - from `default` tags, required properties - from `required:"true"` tags.
+// Unknown properties are not allowed.
+// Defaults of [Secret] fields are not included.
+func GenerateConfigJSONSchema(obj interface{}, options ConfigSchemaOptions) (map[string]interface{}, error) {
+	ew := GetErrorWrapper("GenerateConfigJSONSchema")
+
+	valueType := reflect.TypeOf(obj)
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	if valueType.Kind() != reflect.Struct {
+		return nil, ew(ErrConfigNotStructPointer)
+	}
+
+	schema, err := generateJSONSchemaForType(valueType, "", options)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if options.Title != "" {
+		schema["title"] = options.Title
+	}
+
+	return schema, nil
+}
+
+func generateJSONSchemaForType(valueType reflect.Type, path string, options ConfigSchemaOptions) (map[string]interface{}, error) {
+	schema := map[string]interface{}{}
+
+	if enum, ok := options.Enums[path]; ok {
+		schema["enum"] = enum
+	}
+
+	switch {
+	case valueType == reflect.TypeOf(Secret("")):
+		schema["type"] = "string"
+		schema["description"] = "Secret value, supports references ${file:/path} and ${env:NAME}"
+
+		return schema, nil
+	case valueType == reflect.TypeOf(time.Duration(0)):
+		schema["type"] = "string"
+		schema["description"] = "Duration, e.g. 1m30s"
+
+		return schema, nil
+	}
+
+	//nolint:exhaustive
+	switch valueType.Kind() {
+	case reflect.Pointer:
+		return generateJSONSchemaForType(valueType.Elem(), path, options)
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Slice, reflect.Array:
+		items, err := generateJSONSchemaForType(valueType.Elem(), path+".*", options)
+		if err != nil {
+			return nil, err
+		}
+
+		schema["type"] = "array"
+		schema["items"] = items
+	case reflect.Map:
+		values, err := generateJSONSchemaForType(valueType.Elem(), path+".*", options)
+		if err != nil {
+			return nil, err
+		}
+
+		schema["type"] = "object"
+		schema["additionalProperties"] = values
+	case reflect.Struct:
+		return generateJSONSchemaForStruct(valueType, path, options)
+	default:
+		return nil, &reflect.ValueError{Method: "GenerateConfigJSONSchema", Kind: valueType.Kind()}
+	}
+
+	return schema, nil
+}
+
+func generateJSONSchemaForStruct(valueType reflect.Type, path string, options ConfigSchemaOptions) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+
+		name, ok := ConfigFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinNotEmpty(".", path, name)
+
+		fieldSchema, err := generateJSONSchemaForType(field.Type, fieldPath, options)
+		if err != nil {
+			return nil, err
+		}
+
+		if defaultValue, ok := field.Tag.Lookup("default"); ok && field.Type != reflect.TypeOf(Secret("")) {
+			fieldSchema["default"], err = parseConfigDefault(field.Type, defaultValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if field.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+
+		properties[name] = fieldSchema
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema, nil
+}
+
+// parseConfigDefault parses value of `default` tag the same way as configor does.
+func parseConfigDefault(valueType reflect.Type, raw string) (interface{}, error) {
+	if valueType == reflect.TypeOf(time.Duration(0)) {
+		return raw, nil
+	}
+
+	value := reflect.New(valueType)
+	if strings.TrimSpace(raw) != "" {
+		if err := yaml.Unmarshal([]byte(raw), value.Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	return value.Elem().Interface(), nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConfigInvalid is returned when configuration has at least one invalid value.
+var ErrConfigInvalid = errors.New("invalid config")
+
+// ConfigValidationError describes a problem with one configuration value.
+type ConfigValidationError struct {
+	// Path is a dot-separated YAML path of the value, e.g. "postgresql.port".
+	Path    string
+	Message string
+}
+
+func (e ConfigValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ConfigValidationErrors contains all problems found by [ConfigValidator].
+type ConfigValidationErrors []ConfigValidationError
+
+func (e ConfigValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, validationError := range e {
+		lines = append(lines, "  - "+validationError.Error())
+	}
+
+	return fmt.Sprintf("%s (%d problems):\n%s", ErrConfigInvalid, len(e), strings.Join(lines, "\n"))
+}
+
+// Is makes [ConfigValidationErrors] comparable with [ErrConfigInvalid] by [errors.Is].
+func (e ConfigValidationErrors) Is(target error) bool {
+	return target == ErrConfigInvalid //nolint:errorlint
+}
+
+// ConfigValidator collects configuration problems instead of failing on the first one.
+// Validators created by [ConfigValidator.Section] share the list of problems with their parent.
+type ConfigValidator struct {
+	path   string
+	errors *ConfigValidationErrors
+}
+
+// NewConfigValidator creates a new root [ConfigValidator].
+func NewConfigValidator() *ConfigValidator {
+	return &ConfigValidator{
+		path:   "",
+		errors: &ConfigValidationErrors{},
+	}
+}
+
+// Section returns a validator for nested section, e.g. v.Section("postgresql").
+func (v *ConfigValidator) Section(name string) *ConfigValidator {
+	return &ConfigValidator{
+		path:   joinNotEmpty(".", v.path, name),
+		errors: v.errors,
+	}
+}
+
+// Check adds a problem for value name if ok is false.
+func (v *ConfigValidator) Check(ok bool, name string, format string, args ...any) {
+	if ok {
+		return
+	}
+
+	*v.errors = append(*v.errors, ConfigValidationError{
+		Path:    joinNotEmpty(".", v.path, name),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Required checks that value is not empty.
+func (v *ConfigValidator) Required(name string, value string) {
+	v.Check(strings.TrimSpace(value) != "", name, "must not be empty")
+}
+
+// Port checks that value is a valid TCP/UDP port.
+func (v *ConfigValidator) Port(name string, value int) {
+	v.Check(value > 0 && value <= 65535, name, "must be between 1 and 65535, got %d", value)
+}
+
+// NotNegative checks that value is greater or equal to zero.
+func (v *ConfigValidator) NotNegative(name string, value int64) {
+	v.Check(value >= 0, name, "must not be negative, got %d", value)
+}
+
+// Positive checks that value is greater than zero.
+func (v *ConfigValidator) Positive(name string, value int64) {
+	v.Check(value > 0, name, "must be greater than 0, got %d", value)
+}
+
+// Err returns [ConfigValidationErrors] if any problem was found, otherwise nil.
+func (v *ConfigValidator) Err() error {
+	if len(*v.errors) == 0 {
+		return nil
+	}
+
+	return *v.errors
+}
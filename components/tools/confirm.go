@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrDestructiveActionNotConfirmed is returned when a destructive action was not confirmed.
+var ErrDestructiveActionNotConfirmed = errors.New("destructive action is not confirmed")
+
+// ErrDestructiveActionForbidden is returned when a destructive action is requested in production.
+var ErrDestructiveActionForbidden = errors.New("destructive action is forbidden in production")
+
+// DestructiveAction describes an action which destroys data and requires an explicit confirmation.
+type DestructiveAction struct {
+	// Description is shown to the user, e.g. `recreate tables of postgresql database "app"`.
+	Description string
+	// Confirmation is a value which must be passed or typed to confirm the action, e.g. database name.
+	Confirmation string
+	// ConfirmationEnvName is a name of environment variable which may contain the confirmation value.
+	ConfirmationEnvName string
+}
+
+// ConfirmDestructiveAction checks that the action is confirmed.
+// Uses stdin for interactive prompt if it is a terminal.
+// See [ConfirmDestructiveActionWithInput].
+func ConfirmDestructiveAction(action DestructiveAction, isProduction bool) error {
+	stdinInfo, err := os.Stdin.Stat()
+	isInteractive := err == nil && stdinInfo.Mode()&os.ModeCharDevice != 0
+
+	return ConfirmDestructiveActionWithInput(action, isProduction, os.Stdin, isInteractive, os.Stdout)
+}
+
+// ConfirmDestructiveActionWithInput checks that the action is confirmed:
+//   - in production the action is always refused with [ErrDestructiveActionForbidden]
+//   - the action with empty Confirmation is always refused with [ErrDestructiveActionNotConfirmed],
+//     otherwise empty environment variable or empty input would confirm it
+//   - the action is confirmed if environment variable ConfirmationEnvName equals to Confirmation
+//   - otherwise, if isInteractive, the user is asked to type Confirmation
+//   - otherwise [ErrDestructiveActionNotConfirmed] is returned
+func ConfirmDestructiveActionWithInput(
+	action DestructiveAction,
+	isProduction bool,
+	input io.Reader,
+	isInteractive bool,
+	output io.Writer,
+) error {
+	ew := GetErrorWrapper("ConfirmDestructiveAction")
+
+	if isProduction {
+		return ew(fmt.Errorf("%w: %s", ErrDestructiveActionForbidden, action.Description))
+	}
+
+	if action.Confirmation == "" {
+		return ew(fmt.Errorf("%w: %s: confirmation value is empty", ErrDestructiveActionNotConfirmed, action.Description))
+	}
+
+	if provided, ok := os.LookupEnv(action.ConfirmationEnvName); ok {
+		if provided == action.Confirmation {
+			return nil
+		}
+
+		return ew(fmt.Errorf("%w: %s: value of %s does not match %q",
+			ErrDestructiveActionNotConfirmed, action.Description, action.ConfirmationEnvName, action.Confirmation,
+		))
+	}
+
+	if !isInteractive {
+		return ew(fmt.Errorf("%w: %s: set %s=%s to confirm",
+			ErrDestructiveActionNotConfirmed, action.Description, action.ConfirmationEnvName, action.Confirmation,
+		))
+	}
+
+	_, err := fmt.Fprintf(output, "\033[31mAbout to %s.\033[0m\nType %q to confirm: ", action.Description, action.Confirmation)
+	if err != nil {
+		return ew(err)
+	}
+
+	answer, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return ew(err)
+	}
+
+	if strings.TrimSpace(answer) != action.Confirmation {
+		return ew(fmt.Errorf("%w: %s", ErrDestructiveActionNotConfirmed, action.Description))
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CorrelationIDLogKey is a key of correlation ID in log entries.
+const CorrelationIDLogKey = "correlation_id"
+
+// shortCorrelationIDLength is a length of ID shown to users, see [ShortCorrelationID].
+const shortCorrelationIDLength = 8
+
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a new random correlation ID.
+func NewCorrelationID() string {
+	return uuid.NewString()
+}
+
+// ShortCorrelationID returns a prefix of the ID which is shown to users, so they can report it.
+// Log entries can be found by the prefix.
+func ShortCorrelationID(id string) string {
+	if len(id) > shortCorrelationIDLength {
+		return id[:shortCorrelationIDLength]
+	}
+
+	return id
+}
+
+// WithCorrelationID returns a copy of ctx carrying the ID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// EnsureCorrelationID returns ctx and its correlation ID, a new ID is added if ctx does not carry one.
+// It is used at the start of processing of an update, a console command or a queue message.
+func EnsureCorrelationID(ctx context.Context) (context.Context, string) {
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return ctx, id
+	}
+
+	id := NewCorrelationID()
+
+	return WithCorrelationID(ctx, id), id
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx or an empty string.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+
+	return id
+}
+
+// LoggerWithCorrelationID returns logger which adds the correlation ID of ctx to every entry.
+// logger is returned as is if ctx does not carry an ID.
+func LoggerWithCorrelationID(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	id := CorrelationIDFromContext(ctx)
+	if id == "" {
+		return logger
+	}
+
+	return logger.With(zap.String(CorrelationIDLogKey, id))
+}
+
+// CorrelatedError is an error which occurred while processing an update, a command or a message with the ID.
+type CorrelatedError struct {
+	ID  string
+	Err error
+}
+
+// Error implements error, the ID is added after the message.
+func (e *CorrelatedError) Error() string {
+	return fmt.Sprintf("%s [%s=%s]", e.Err.Error(), CorrelationIDLogKey, e.ID)
+}
+
+// Unwrap returns the original error.
+func (e *CorrelatedError) Unwrap() error {
+	return e.Err
+}
+
+// CorrelationIDFromError returns the correlation ID of err or an empty string.
+func CorrelationIDFromError(err error) string {
+	var correlatedError *CorrelatedError
+	if errors.As(err, &correlatedError) {
+		return correlatedError.ID
+	}
+
+	return ""
+}
+
+// WrapMethodErrorContext wraps an error with method name like [WrapMethodError]
+// and adds the correlation ID of ctx if err does not contain it yet.
+func WrapMethodErrorContext(ctx context.Context, err error, method string, keysAndValues ...interface{}) error {
+	return wrapMethodErrorContext(ctx, err, "", method, keysAndValues, 1)
+}
+
+func wrapMethodErrorContext(
+	ctx context.Context,
+	err error,
+	component, method string,
+	keysAndValues []interface{},
+	skip int,
+) error {
+	if err == nil {
+		return nil
+	}
+
+	wrapped := wrapMethodError(err, component, method, keysAndValues, skip+1)
+
+	id := CorrelationIDFromContext(ctx)
+	if id == "" || CorrelationIDFromError(err) == id {
+		return wrapped
+	}
+
+	return &CorrelatedError{ID: id, Err: wrapped}
+}
@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DownloadPartSuffix is appended to the target path of [Download] for the file being downloaded.
+const DownloadPartSuffix = ".part"
+
+// ErrDownloadTooLarge is returned by [Download] when the file is larger than [DownloadOptions.MaxSize].
+var ErrDownloadTooLarge = errors.New("file is too large")
+
+// ErrDownloadChecksumMismatch is returned by [Download] when SHA-256 of the file differs from the expected one.
+var ErrDownloadChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrDownloadInvalidRange is returned by [Download] when a server responds with a range other than requested.
+var ErrDownloadInvalidRange = errors.New("invalid range in response")
+
+// DownloadProgress is passed to [DownloadOptions.OnProgress].
+type DownloadProgress struct {
+	// Downloaded is a size of the file including the resumed part.
+	Downloaded int64
+	// Total is a size of the whole file, -1 if the server did not report it.
+	Total int64
+}
+
+// DownloadOptions describes how [Download] gets and checks a file.
+type DownloadOptions struct {
+	// Client sends requests, default is [http.DefaultClient].
+	Client *http.Client
+	// Header is added to requests, e.g. Authorization.
+	Header http.Header
+	// SHA256 is an expected hex checksum of the file, empty means it is not verified.
+	SHA256 string
+	// MaxSize limits the size of the file in bytes, 0 means no limit.
+	MaxSize int64
+	// Resume continues a partial file left by a failed download with HTTP Range request.
+	// Without it the partial file is removed on failure, with it only an invalid partial file is removed.
+	Resume bool
+	// OnProgress is called after every written chunk of the file.
+	OnProgress func(progress DownloadProgress)
+	// Logger receives start and end of the download, nil disables logging.
+	Logger *zap.Logger
+}
+
+// DownloadResult describes a downloaded file.
+type DownloadResult struct {
+	Path   string
+	Size   int64
+	SHA256 string
+	// ResumedFrom is a size of the partial file which was continued, 0 if the file was downloaded from the beginning.
+	ResumedFrom int64
+}
+
+// Download downloads a file from url to path.
+// The file is written to path with [DownloadPartSuffix] and renamed to path only when it is complete
+// and checked, so path never contains a partial file.
+// Unexpected statuses are returned as [HTTPStatusError] classified by the status.
+func Download(ctx context.Context, path string, url string, options DownloadOptions) (*DownloadResult, error) {
+	ew := NewErrorWrapperCreator().GetContextMethodWrapper(ctx, "Download", "path", path)
+
+	logger := options.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	d := &downloader{
+		url:     url,
+		options: options,
+		client:  FirstNonEmpty(options.Client, http.DefaultClient),
+		logger:  LoggerWithCorrelationID(ctx, logger.Named("Download")).With(zap.String("path", path)),
+		hash:    sha256.New(),
+	}
+
+	partPath := path + DownloadPartSuffix
+
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644) //nolint:gosec
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	d.file = file
+
+	result, err := d.download(ctx)
+	closeErr := file.Close()
+
+	if err == nil && closeErr != nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(partPath, path)
+	}
+
+	if err != nil {
+		if !options.Resume || d.discardPart {
+			_ = os.Remove(partPath)
+		}
+
+		return nil, ew(err)
+	}
+
+	result.Path = path
+
+	return result, nil
+}
+
+type downloader struct {
+	url     string
+	options DownloadOptions
+	client  *http.Client
+	logger  *zap.Logger
+	file    *os.File
+	hash    hash.Hash
+	// discardPart means that the partial file cannot be continued after the failure
+	discardPart bool
+}
+
+func (d *downloader) download(ctx context.Context) (*DownloadResult, error) {
+	start := time.Now()
+
+	offset, err := d.prepareFile()
+	if err != nil {
+		return nil, err
+	}
+
+	response, total, err := d.request(ctx, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// the partial file is reset if the server ignored the range
+	offset, err = d.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.options.MaxSize > 0 && total > d.options.MaxSize {
+		d.discardPart = true
+		return nil, newErrDownloadTooLarge(total, d.options.MaxSize)
+	}
+
+	d.logger.Info("Downloading file",
+		zap.String("url", d.url),
+		zap.Int64("offset", offset),
+		zap.Int64("total", total),
+	)
+
+	body := io.Reader(response.Body)
+	if d.options.MaxSize > 0 {
+		body = io.LimitReader(body, d.options.MaxSize-offset+1)
+	}
+
+	writer := &downloadProgressWriter{
+		writer:     io.MultiWriter(d.file, d.hash),
+		downloaded: offset,
+		total:      total,
+		onProgress: d.options.OnProgress,
+	}
+
+	_, err = io.Copy(writer, body)
+	if err != nil {
+		return nil, ClassifyError(err, ErrorCategoryRetryable, "download.interrupted", "")
+	}
+
+	if d.options.MaxSize > 0 && writer.downloaded > d.options.MaxSize {
+		d.discardPart = true
+		return nil, newErrDownloadTooLarge(writer.downloaded, d.options.MaxSize)
+	}
+
+	if total >= 0 && writer.downloaded != total {
+		return nil, ClassifyError(
+			fmt.Errorf("%w: got %d of %d bytes", io.ErrUnexpectedEOF, writer.downloaded, total),
+			ErrorCategoryRetryable,
+			"download.interrupted",
+			"",
+		)
+	}
+
+	checksum := hex.EncodeToString(d.hash.Sum(nil))
+
+	if d.options.SHA256 != "" && !strings.EqualFold(checksum, d.options.SHA256) {
+		d.discardPart = true
+
+		return nil, ClassifyError(
+			fmt.Errorf("%w: expected %s got %s", ErrDownloadChecksumMismatch, d.options.SHA256, checksum),
+			ErrorCategoryPermanent,
+			"download.checksum_mismatch",
+			"",
+		)
+	}
+
+	d.logger.Info("File downloaded",
+		zap.Int64("size", writer.downloaded),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return &DownloadResult{
+		Size:        writer.downloaded,
+		SHA256:      checksum,
+		ResumedFrom: offset,
+	}, nil
+}
+
+// prepareFile hashes the partial file if it is resumed or truncates it otherwise.
+// Returns the offset to continue from.
+func (d *downloader) prepareFile() (int64, error) {
+	if !d.options.Resume {
+		return 0, d.resetFile()
+	}
+
+	offset, err := io.Copy(d.hash, d.file)
+	if err != nil {
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+func (d *downloader) resetFile() error {
+	d.hash.Reset()
+
+	if err := d.file.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := d.file.Seek(0, io.SeekStart)
+
+	return err
+}
+
+// request gets the file from offset, the partial file is reset if the server cannot continue it.
+// Returns the response and the size of the whole file, -1 if it is unknown.
+func (d *downloader) request(ctx context.Context, offset int64) (*http.Response, int64, error) {
+	for {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for key, values := range d.options.Header {
+			request.Header[key] = values
+		}
+
+		if offset > 0 {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+
+		response, err := d.client.Do(request)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case response.StatusCode == http.StatusOK:
+			if offset > 0 {
+				d.logger.Info("Server does not support ranges, downloading from the beginning")
+
+				if err := d.resetFile(); err != nil {
+					response.Body.Close()
+					return nil, 0, err
+				}
+			}
+
+			return response, response.ContentLength, nil
+		case offset > 0 && response.StatusCode == http.StatusPartialContent:
+			first, total, ok := parseContentRange(response.Header.Get("Content-Range"))
+			if !ok || first != offset {
+				response.Body.Close()
+
+				d.discardPart = true
+
+				return nil, 0, fmt.Errorf("%w: requested from %d, got %q",
+					ErrDownloadInvalidRange, offset, response.Header.Get("Content-Range"))
+			}
+
+			return response, total, nil
+		case offset > 0 && response.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+			response.Body.Close()
+
+			// the partial file is already complete
+			if _, total, _ := parseContentRange(response.Header.Get("Content-Range")); total == offset {
+				response.Body = http.NoBody
+				return response, total, nil
+			}
+
+			if err := d.resetFile(); err != nil {
+				return nil, 0, err
+			}
+
+			offset = 0
+		default:
+			response.Body.Close()
+
+			expected := http.StatusOK
+			if offset > 0 {
+				expected = http.StatusPartialContent
+			}
+
+			return nil, 0, NewErrHTTPWrongStatus(expected, response.StatusCode)
+		}
+	}
+}
+
+// parseContentRange parses "bytes <first>-<last>/<total>" or "bytes */<total>", unknown total is -1.
+func parseContentRange(contentRange string) (int64, int64, bool) {
+	rangeAndTotal, found := strings.CutPrefix(contentRange, "bytes ")
+	if !found {
+		return 0, -1, false
+	}
+
+	byteRange, totalString, found := strings.Cut(rangeAndTotal, "/")
+	if !found {
+		return 0, -1, false
+	}
+
+	total := int64(-1)
+
+	if totalString != "*" {
+		var err error
+
+		total, err = strconv.ParseInt(totalString, 10, 64)
+		if err != nil {
+			return 0, -1, false
+		}
+	}
+
+	firstString, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, total, byteRange == "*"
+	}
+
+	first, err := strconv.ParseInt(firstString, 10, 64)
+	if err != nil {
+		return 0, total, false
+	}
+
+	return first, total, true
+}
+
+func newErrDownloadTooLarge(size int64, maxSize int64) error {
+	return ClassifyError(
+		fmt.Errorf("%w: %d bytes, limit is %d", ErrDownloadTooLarge, size, maxSize),
+		ErrorCategoryInvalidInput,
+		"download.too_large",
+		"",
+	)
+}
+
+type downloadProgressWriter struct {
+	writer     io.Writer
+	downloaded int64
+	total      int64
+	onProgress func(progress DownloadProgress)
+}
+
+func (w *downloadProgressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.downloaded += int64(n)
+
+	if w.onProgress != nil && n > 0 {
+		w.onProgress(DownloadProgress{Downloaded: w.downloaded, Total: w.total})
+	}
+
+	return n, err
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrorCategory tells how an error should be handled: retried, reported to the user or fixed by developers.
+type ErrorCategory string
+
+// Categories of errors, see [ClassifyError].
+const (
+	// ErrorCategoryUnknown is a category of errors which are not classified.
+	ErrorCategoryUnknown ErrorCategory = "unknown"
+	// ErrorCategoryRetryable errors are temporary, e.g. network failures, timeouts and throttling.
+	ErrorCategoryRetryable ErrorCategory = "retryable"
+	// ErrorCategoryPermanent errors are not fixed by retrying, e.g. schema errors and disabled components.
+	ErrorCategoryPermanent ErrorCategory = "permanent"
+	// ErrorCategoryNotFound errors mean that a requested entity does not exist.
+	ErrorCategoryNotFound ErrorCategory = "not_found"
+	// ErrorCategoryForbidden errors mean that credentials or permissions are not sufficient.
+	ErrorCategoryForbidden ErrorCategory = "forbidden"
+	// ErrorCategoryInvalidInput errors mean that the request is malformed or violates constraints.
+	ErrorCategoryInvalidInput ErrorCategory = "invalid_input"
+)
+
+// defaultUserMessages are shown to users for errors without [ClassifiedError.UserMessage].
+var defaultUserMessages = map[ErrorCategory]string{
+	ErrorCategoryUnknown:      "Something went wrong",
+	ErrorCategoryRetryable:    "Service is temporarily unavailable, please try again later",
+	ErrorCategoryPermanent:    "Something went wrong",
+	ErrorCategoryNotFound:     "Requested object is not found",
+	ErrorCategoryForbidden:    "Access denied",
+	ErrorCategoryInvalidInput: "Invalid request",
+}
+
+// ClassifiedError is an error with a category, a stable code and a message which is safe to show to users.
+// The message of the error is not changed by classification.
+type ClassifiedError struct {
+	Category ErrorCategory
+	// Code is a stable identifier of the error, e.g. "postgres.23505" or "s3.NoSuchBucket".
+	Code string
+	// UserMessage is shown to users instead of the default message of the category if it is not empty.
+	UserMessage string
+	Err         error
+}
+
+// Error implements error.
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError adds category, code and optional user-safe message to err.
+// Returns nil if err is nil.
+func ClassifyError(err error, category ErrorCategory, code string, userMessage string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ClassifiedError{
+		Category:    category,
+		Code:        code,
+		UserMessage: userMessage,
+		Err:         err,
+	}
+}
+
+// GetErrorCategory returns the category of the outermost [ClassifiedError] in the chain of err.
+// Errors which are not classified are categorized by common rules:
+// timeouts and network errors are retryable, cancellation and disabled components are permanent.
+func GetErrorCategory(err error) ErrorCategory {
+	var classifiedError *ClassifiedError
+	if errors.As(err, &classifiedError) {
+		return classifiedError.Category
+	}
+
+	var netError net.Error
+
+	switch {
+	case err == nil:
+		return ErrorCategoryUnknown
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netError):
+		return ErrorCategoryRetryable
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrComponentDisabled):
+		return ErrorCategoryPermanent
+	default:
+		return ErrorCategoryUnknown
+	}
+}
+
+// GetErrorCode returns the code of err or its category if err is not classified.
+func GetErrorCode(err error) string {
+	var classifiedError *ClassifiedError
+	if errors.As(err, &classifiedError) && classifiedError.Code != "" {
+		return classifiedError.Code
+	}
+
+	return string(GetErrorCategory(err))
+}
+
+// GetUserMessage returns a message of err which is safe to show to users.
+// Texts of errors may contain internal details, so only [ClassifiedError.UserMessage]
+// or the default message of the category is returned.
+func GetUserMessage(err error) string {
+	var classifiedError *ClassifiedError
+	if errors.As(err, &classifiedError) && classifiedError.UserMessage != "" {
+		return classifiedError.UserMessage
+	}
+
+	return defaultUserMessages[GetErrorCategory(err)]
+}
+
+// IsRetryableError reports whether an operation failed with err may succeed if it is repeated.
+func IsRetryableError(err error) bool {
+	return GetErrorCategory(err) == ErrorCategoryRetryable
+}
+
+// GetHTTPStatusErrorCategory returns the category of an error response with the HTTP status.
+func GetHTTPStatusErrorCategory(status int) ErrorCategory {
+	switch {
+	case status == http.StatusNotFound, status == http.StatusGone:
+		return ErrorCategoryNotFound
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrorCategoryForbidden
+	case status == http.StatusRequestTimeout, status == http.StatusTooEarly, status == http.StatusTooManyRequests,
+		status >= http.StatusInternalServerError && status != http.StatusNotImplemented:
+		return ErrorCategoryRetryable
+	case status >= http.StatusBadRequest:
+		return ErrorCategoryInvalidInput
+	default:
+		return ErrorCategoryPermanent
+	}
+}
@@ -1,29 +1,259 @@
 package tools
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // WrapMethodError wrap an error with method name.
-func WrapMethodError(err error, method string) error {
+// keysAndValues are added to the error as fields, see [MethodError].
+func WrapMethodError(err error, method string, keysAndValues ...interface{}) error {
+	return wrapMethodError(err, "", method, keysAndValues, 1)
+}
+
+// wrapMethodError creates [MethodError], skip is a number of wrapper functions between it and the caller,
+// which are not included in the stack.
+func wrapMethodError(err error, component, method string, keysAndValues []interface{}, skip int) error {
 	if err == nil {
 		return nil
 	}
 
-	return fmt.Errorf("%s: %w", method, err)
+	methodError := &MethodError{
+		Component: component,
+		Method:    method,
+		Fields:    newErrorFields(keysAndValues),
+		Err:       err,
+	}
+
+	// the stack of the first wrap points to the place where the error occurred
+	var wrapped *MethodError
+	if !errors.As(err, &wrapped) {
+		stack := make([]uintptr, maxErrorStackDepth)
+		methodError.stack = stack[:runtime.Callers(skip+2, stack)]
+	}
+
+	return methodError
+}
+
+// maxErrorStackDepth is a maximal number of frames in the stack of [MethodError].
+const maxErrorStackDepth = 32
+
+// badErrorFieldKey is a key of values passed to error wrappers without a key, the same as in log/slog.
+const badErrorFieldKey = "!BADKEY"
+
+// ErrorField is a key/value pair added to [MethodError].
+type ErrorField struct {
+	Key   string
+	Value interface{}
+}
+
+// newErrorFields converts alternating keys and values to fields.
+// A value without a string key is added with [badErrorFieldKey].
+func newErrorFields(keysAndValues []interface{}) []ErrorField {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	fields := make([]ErrorField, 0, (len(keysAndValues)+1)/2)
+
+	for i := 0; i < len(keysAndValues); i++ {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			fields = append(fields, ErrorField{Key: badErrorFieldKey, Value: keysAndValues[i]})
+			continue
+		}
+
+		fields = append(fields, ErrorField{Key: key, Value: keysAndValues[i+1]})
+		i++
+	}
+
+	return fields
+}
+
+// MethodError is an error returned by a method of a component, see [ErrorWrapperCreator.GetMethodWrapper].
+// The stack is captured when the first MethodError in the chain is created,
+// so it points to the place where the original error was returned.
+//
+// MethodError implements [zapcore.ObjectMarshaler], the logger of utils.NewLogger adds the object as "errorDetails" field
+// for every error logged with [zap.Error], see [ErrorDetails]. Other loggers write only the message of the error
+// unless their core is wrapped by utils.NewErrorDetailsCore.
+type MethodError struct {
+	// Component is the prefix of [ErrorWrapperCreator], e.g. "ClickHouse". It is empty for [WrapMethodError].
+	Component string
+	Method    string
+	Fields    []ErrorField
+	Err       error
+	stack     []uintptr
+}
+
+// Error implements error, the format is "Component.Method: message".
+func (e *MethodError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name(), e.Err.Error())
+}
+
+// Unwrap returns the wrapped error.
+func (e *MethodError) Unwrap() error {
+	return e.Err
+}
+
+// Name returns the full method name, e.g. "ClickHouse.GetConnection".
+func (e *MethodError) Name() string {
+	if e.Component == "" {
+		return e.Method
+	}
+
+	return fmt.Sprintf("%s.%s", e.Component, e.Method)
+}
+
+// Stack returns the stack captured by the error or an empty string if it was not the first wrap.
+// The format is the same as in zap stacktraces.
+func (e *MethodError) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	builder := strings.Builder{}
+	frames := runtime.CallersFrames(e.stack)
+
+	for {
+		frame, more := frames.Next()
+
+		if builder.Len() > 0 {
+			builder.WriteByte('\n')
+		}
+
+		fmt.Fprintf(&builder, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+
+		if !more {
+			return builder.String()
+		}
+	}
+}
+
+// MarshalLogObject implements [zapcore.ObjectMarshaler], see [ErrorDetails].
+func (e *MethodError) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	return errorDetails{err: e, method: e}.MarshalLogObject(encoder)
+}
+
+// ErrorStack returns the stack of the first [MethodError] wrap in the chain of err or an empty string.
+func ErrorStack(err error) string {
+	stack := ""
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if methodError, ok := err.(*MethodError); ok && len(methodError.stack) > 0 { //nolint:errorlint
+			stack = methodError.Stack()
+		}
+	}
+
+	return stack
+}
+
+// ErrorFields returns fields of all [MethodError] in the chain of err, from the outermost one.
+func ErrorFields(err error) []ErrorField {
+	fields := []ErrorField{}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if methodError, ok := err.(*MethodError); ok { //nolint:errorlint
+			fields = append(fields, methodError.Fields...)
+		}
+	}
+
+	return fields
+}
+
+// ErrorDetails returns structured details of err for logging:
+// component and method of the outermost [MethodError], fields of the chain, the category and the code
+// of [ClassifiedError], the stack and the correlation ID.
+// It returns false if err does not contain [MethodError].
+//
+//nolint:ireturn
+func ErrorDetails(err error) (zapcore.ObjectMarshaler, bool) {
+	var methodError *MethodError
+	if !errors.As(err, &methodError) {
+		return nil, false
+	}
+
+	return errorDetails{err: err, method: methodError}, true
+}
+
+// errorDetails is a log object of [ErrorDetails].
+type errorDetails struct {
+	err    error
+	method *MethodError
+}
+
+// MarshalLogObject implements [zapcore.ObjectMarshaler].
+func (d errorDetails) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	if d.method.Component != "" {
+		encoder.AddString("component", d.method.Component)
+	}
+
+	encoder.AddString("method", d.method.Method)
+
+	if fields := ErrorFields(d.err); len(fields) > 0 {
+		_ = encoder.AddObject("fields", zapcore.ObjectMarshalerFunc(func(encoder zapcore.ObjectEncoder) error {
+			for _, field := range fields {
+				zap.Any(field.Key, field.Value).AddTo(encoder)
+			}
+
+			return nil
+		}))
+	}
+
+	var classifiedError *ClassifiedError
+	if errors.As(d.err, &classifiedError) {
+		encoder.AddString("category", string(classifiedError.Category))
+		encoder.AddString("code", classifiedError.Code)
+	}
+
+	if id := CorrelationIDFromError(d.err); id != "" {
+		encoder.AddString(CorrelationIDLogKey, id)
+	}
+
+	if stack := ErrorStack(d.err); stack != "" {
+		encoder.AddString("stack", stack)
+	}
+
+	return nil
 }
 
 // ErrHTTPWrongStatus is an error type for wrong HTTP status.
 var ErrHTTPWrongStatus = errors.New("wrong status")
 
-// NewErrHTTPWrongStatus is a function for creating [ErrHTTPWrongStatus].
+// HTTPStatusError is an unexpected HTTP status of a response, it matches [ErrHTTPWrongStatus].
+type HTTPStatusError struct {
+	Expected int
+	Got      int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: expected %d got %d", ErrHTTPWrongStatus, e.Expected, e.Got)
+}
+
+// Is reports whether target is [ErrHTTPWrongStatus].
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrHTTPWrongStatus
+}
+
+// NewErrHTTPWrongStatus is a function for creating [HTTPStatusError].
+// The error is classified by the got status, see [GetHTTPStatusErrorCategory].
 //
 // Accepts two parameters:
 //   - expected - expected HTTP status
 //   - got - got HTTP status
 func NewErrHTTPWrongStatus(expected int, got int) error {
-	return fmt.Errorf("%w: expected %d got %d", ErrHTTPWrongStatus, expected, got)
+	return ClassifyError(
+		&HTTPStatusError{Expected: expected, Got: got},
+		GetHTTPStatusErrorCategory(got),
+		fmt.Sprintf("http.%d", got),
+		"",
+	)
 }
 
 // ErrorWrapperCreator creates functions for wrapping errors.
@@ -54,14 +284,39 @@ func (w ErrorWrapperCreator) concatWithPrefix(content string) string {
 	return fmt.Sprintf("%s.%s", w.Prefix, content)
 }
 
-// GetMethodWrapper returns a function for wrapping errors.
-func (w ErrorWrapperCreator) GetMethodWrapper(methodName string) func(err error) error {
-	return GetErrorWrapper(w.concatWithPrefix(methodName))
+// GetMethodWrapper returns a function for wrapping errors into [MethodError].
+// keysAndValues are added to every wrapped error as fields, e.g. GetMethodWrapper("GetConnection", "vhost", vhost).
+func (w ErrorWrapperCreator) GetMethodWrapper(methodName string, keysAndValues ...interface{}) func(err error) error {
+	return func(err error) error {
+		return wrapMethodError(err, w.Prefix, methodName, keysAndValues, 1)
+	}
+}
+
+// GetContextMethodWrapper returns a function for wrapping errors, which adds the correlation ID of ctx,
+// see [WrapMethodErrorContext].
+func (w ErrorWrapperCreator) GetContextMethodWrapper(
+	ctx context.Context,
+	methodName string,
+	keysAndValues ...interface{},
+) func(err error) error {
+	return func(err error) error {
+		return wrapMethodErrorContext(ctx, err, w.Prefix, methodName, keysAndValues, 1)
+	}
 }
 
 // GetErrorWrapper returns a function for wrapping errors.
-func GetErrorWrapper(prefix string) func(err error) error {
+func GetErrorWrapper(prefix string, keysAndValues ...interface{}) func(err error) error {
 	return func(err error) error {
-		return WrapMethodError(err, prefix)
+		return wrapMethodError(err, "", prefix, keysAndValues, 1)
 	}
 }
+
+// ErrComponentDisabled is returned when a component disabled in config is used.
+var ErrComponentDisabled = errors.New("component is disabled")
+
+// NewErrComponentDisabled is a function for creating [ErrComponentDisabled].
+//
+// Accepts section - YAML section of the component in config, e.g. "clickhouse".
+func NewErrComponentDisabled(section string) error {
+	return fmt.Errorf("%w: %s (set %s.is_enabled to true in config)", ErrComponentDisabled, section, section)
+}
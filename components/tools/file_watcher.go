@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+type watchedFileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// FileWatcher polls files and calls a callback when any of them is changed, created or removed.
+// Polling is used instead of filesystem notifications because editors and
+// orchestrators often replace files (e.g. Kubernetes ConfigMap symlinks) instead of writing them.
+type FileWatcher struct {
+	paths    []string
+	interval time.Duration
+	onChange func()
+	states   map[string]watchedFileState
+}
+
+// NewFileWatcher creates a new [FileWatcher].
+// Current state of files is remembered immediately, so only further changes trigger onChange.
+func NewFileWatcher(paths []string, interval time.Duration, onChange func()) *FileWatcher {
+	w := &FileWatcher{
+		paths:    paths,
+		interval: interval,
+		onChange: onChange,
+		states:   map[string]watchedFileState{},
+	}
+
+	w.poll()
+
+	return w
+}
+
+// Run polls files until ctx is done.
+func (w *FileWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.poll() {
+				w.onChange()
+			}
+		}
+	}
+}
+
+// poll updates states of files and reports whether any file was changed.
+func (w *FileWatcher) poll() bool {
+	changed := false
+
+	for _, path := range w.paths {
+		state := watchedFileState{}
+
+		if info, err := os.Stat(path); err == nil {
+			state = watchedFileState{
+				exists:  true,
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+		}
+
+		if previous, ok := w.states[path]; ok && previous != state {
+			changed = true
+		}
+
+		w.states[path] = state
+	}
+
+	return changed
+}
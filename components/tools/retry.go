@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetryConfig is a policy of repeating failed operations with exponential backoff, see [Retry].
+type RetryConfig struct {
+	// MaxAttempts is a maximal number of attempts including the first one, 0 means no limit.
+	MaxAttempts int
+	// InitialInterval is a delay after the first failed attempt.
+	InitialInterval time.Duration
+	// MaxInterval limits delays between attempts.
+	MaxInterval time.Duration
+	// Multiplier increases the delay after every failed attempt.
+	Multiplier float64
+	// Jitter is a part of the delay which is randomized, e.g. 0.2 means ±20%.
+	Jitter float64
+	// MaxElapsedTime limits the time since the first attempt, 0 means no limit.
+	// An attempt is not started if it would begin after the limit.
+	MaxElapsedTime time.Duration
+}
+
+// Validate checks configuration values.
+func (c *RetryConfig) Validate(v *ConfigValidator) {
+	v.NotNegative("max_attempts", int64(c.MaxAttempts))
+	v.Positive("initial_interval", int64(c.InitialInterval))
+	v.Check(c.MaxInterval >= c.InitialInterval, "max_interval",
+		"must not be less than initial_interval, got %s", c.MaxInterval)
+	v.Check(c.Multiplier >= 1, "multiplier", "must not be less than 1, got %g", c.Multiplier)
+	v.Check(c.Jitter >= 0 && c.Jitter <= 1, "jitter", "must be between 0 and 1, got %g", c.Jitter)
+	v.NotNegative("max_elapsed_time", int64(c.MaxElapsedTime))
+	v.Check(c.MaxAttempts > 0 || c.MaxElapsedTime > 0, "max_elapsed_time",
+		"must be greater than 0 if max_attempts is not limited")
+}
+
+// GetInterval returns the delay before the attempt following the failed one with the number (starting from 1).
+// random is a number in [0, 1) used for jitter.
+func (c *RetryConfig) GetInterval(failedAttempt int, random float64) time.Duration {
+	interval := float64(c.InitialInterval) * math.Pow(c.Multiplier, float64(failedAttempt-1))
+	interval = min(interval, float64(c.MaxInterval))
+	interval *= 1 + c.Jitter*(2*random-1)
+
+	return time.Duration(interval)
+}
+
+// RetryOptions describes how [Retry] repeats an operation.
+type RetryOptions struct {
+	// Config is a policy of retrying, nil means a single attempt.
+	Config *RetryConfig
+	// IsRetryable decides whether a failed attempt is repeated, default is [IsRetryableError].
+	IsRetryable func(err error) bool
+	// Logger receives failed attempts, nil disables logging.
+	Logger *zap.Logger
+}
+
+// Retry calls operation until it succeeds, fails with an error which is not retryable
+// or limits of options.Config are exceeded. Delays grow exponentially and are randomized by jitter.
+// Waiting is interrupted when ctx is done, the returned error matches both ctx.Err() and the last error.
+func Retry(ctx context.Context, options RetryOptions, operation func(ctx context.Context) error) error {
+	config := options.Config
+	if config == nil {
+		config = &RetryConfig{MaxAttempts: 1}
+	}
+
+	isRetryable := options.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsRetryableError
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	logger = LoggerWithCorrelationID(ctx, logger)
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := operation(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("Attempt succeeded", zap.Int("attempt", attempt))
+			}
+
+			return nil
+		}
+
+		if !isRetryable(err) {
+			return WrapMethodError(err, "Retry", "attempts", attempt)
+		}
+
+		interval := config.GetInterval(attempt, rand.Float64()) //nolint:gosec
+
+		if config.MaxAttempts > 0 && attempt >= config.MaxAttempts ||
+			config.MaxElapsedTime > 0 && time.Since(start)+interval > config.MaxElapsedTime {
+			return WrapMethodError(err, "Retry", "attempts", attempt)
+		}
+
+		logger.Warn("Attempt failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", interval),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return WrapMethodError(fmt.Errorf("%w (last error: %w)", ctx.Err(), err), "Retry", "attempts", attempt)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// SecretMask is printed instead of values of [Secret].
+const SecretMask = "***"
+
+// ErrSecretReference is returned when a secret reference cannot be resolved.
+var ErrSecretReference = errors.New("invalid secret reference")
+
+// Secret is a string which is never printed or logged.
+// Use [Secret.Value] to get the real value.
+//
+// Value of a secret in config may be a reference which is resolved by [ResolveSecrets]:
+//   - ${file:/run/secrets/pg} - content of the file without trailing newlines
+//   - ${env:PG_PASS} - value of the environment variable
+type Secret string
+
+// Value returns the real value of the secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements [fmt.Stringer].
+func (s Secret) String() string {
+	return SecretMask
+}
+
+// GoString implements [fmt.GoStringer], used by %#v.
+func (s Secret) GoString() string {
+	return SecretMask
+}
+
+// MarshalJSON implements [encoding/json.Marshaler].
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + SecretMask + `"`), nil
+}
+
+// MarshalYAML implements [gopkg.in/yaml.v3.Marshaler].
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return SecretMask, nil
+}
+
+// ResolveSecrets replaces references in all [Secret] fields of obj by their values.
+// obj must be a pointer to a struct, nested structs are processed recursively.
+func ResolveSecrets(obj interface{}) error {
+	ew := GetErrorWrapper("ResolveSecrets")
+
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return ew(ErrConfigNotStructPointer)
+	}
+
+	return ew(resolveSecrets(value.Elem(), ""))
+}
+
+func resolveSecrets(value reflect.Value, path string) error {
+	valueType := value.Type()
+	secretType := reflect.TypeOf(Secret(""))
+
+	for i := range valueType.NumField() {
+		name, ok := ConfigFieldName(valueType.Field(i))
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinNotEmpty(".", path, name)
+		fieldValue := value.Field(i)
+
+		switch {
+		case isConfigSection(fieldValue.Type()):
+			if err := resolveSecrets(fieldValue, fieldPath); err != nil {
+				return err
+			}
+		case fieldValue.Type() == secretType:
+			resolved, err := ResolveSecretReference(fieldValue.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", fieldPath, err)
+			}
+
+			fieldValue.SetString(resolved)
+		}
+	}
+
+	return nil
+}
+
+// ResolveSecretReference returns value of the reference like ${file:/path} or ${env:NAME}.
+// Values which are not references are returned as is.
+func ResolveSecretReference(raw string) (string, error) {
+	if !strings.HasPrefix(raw, "${") || !strings.HasSuffix(raw, "}") {
+		return raw, nil
+	}
+
+	provider, argument, found := strings.Cut(raw[2:len(raw)-1], ":")
+	if !found || argument == "" {
+		return "", fmt.Errorf("%w: expected ${provider:argument}", ErrSecretReference)
+	}
+
+	switch provider {
+	case "file":
+		content, err := os.ReadFile(argument)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrSecretReference, err)
+		}
+
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case "env":
+		envValue, ok := os.LookupEnv(argument)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrSecretReference, argument)
+		}
+
+		return envValue, nil
+	default:
+		return "", fmt.Errorf("%w: unknown provider %q", ErrSecretReference, provider)
+	}
+}
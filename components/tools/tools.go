@@ -4,10 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
-	"github.com/jinzhu/configor"
-	"io"
 	"math/big"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -43,6 +40,7 @@ func GetPathFromRoot(path string) string {
 }
 
 // ExecuteCommandWithOutput executes the given command and returns the output.
+// Use [RunCommand] for a context, stderr, exit code and streaming output to logs.
 //
 // Accepts:
 //   - cmd *[exec.Cmd] - command for executing
@@ -100,7 +98,8 @@ func DownloadFile(filepath string, url string, logger *zap.Logger) error {
 	return DownloadFileWithContext(context.Background(), filepath, url, logger)
 }
 
-// DownloadFileWithContext download a file from URL to specific filepath with context
+// DownloadFileWithContext download a file from URL to specific filepath with context,
+// see [Download] for checksum, size limit, resuming and progress.
 //
 // Accepts:
 //   - ctx context.Context - context
@@ -111,41 +110,9 @@ func DownloadFile(filepath string, url string, logger *zap.Logger) error {
 // Returns:
 //   - error - nil or error if any error occurred
 func DownloadFileWithContext(ctx context.Context, filepath string, url string, logger *zap.Logger) error {
-	ew := GetErrorWrapper("DownloadFileWithContext")
-	logger = logger.Named("DownloadFileWithContext")
+	_, err := Download(ctx, filepath, url, DownloadOptions{Logger: logger})
 
-	logger.Info("Downloading file", zap.String("url", url), zap.String("filepath", filepath))
-
-	// Create the file
-	out, err := os.Create(filepath)
-	if err != nil {
-		return WrapMethodError(err, "DownloadFileWithContext")
-	}
-	defer out.Close()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return WrapMethodError(err, "DownloadFileWithContext")
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return WrapMethodError(err, "DownloadFileWithContext")
-	}
-	defer resp.Body.Close()
-
-	// Check server response
-	if resp.StatusCode != http.StatusOK {
-		return ew(err)
-	}
-
-	// Writer the body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return ew(err)
-	}
-
-	return nil
+	return WrapMethodErrorContext(ctx, err, "DownloadFileWithContext")
 }
 
 // GenerateUUID generate a UUID string.
@@ -204,28 +171,3 @@ func CountdownCmd(ctx context.Context, message string, delay time.Duration, coun
 func RedOutputCmd(message string) {
 	fmt.Println("\033[31m" + message + "\033[0m")
 }
-
-func LoadConfig(paths []string, obj interface{}) error {
-	configPaths := []string{}
-
-	for _, path := range paths {
-		if _, err := os.Stat(path); err != nil {
-			if os.IsNotExist(err) {
-				fmt.Printf("Config file not found: %s\n", path)
-			} else {
-				fmt.Printf("Skip file because an error occurred while checking the file: %s\n", path)
-			}
-			return err
-		} else {
-			configPaths = append(configPaths, path)
-		}
-	}
-
-	err := configor.Load(obj, configPaths...)
-	if err != nil {
-		err = fmt.Errorf("LoadConfig: %w", err)
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// adminHTTPShutdownTimeout limits waiting for active requests on shutdown.
+const adminHTTPShutdownTimeout = 5 * time.Second
+
+type AdminHTTPConfig struct {
+	IsEnabled bool
+	Address   string
+	// Token is required in `Authorization: Bearer <token>` header of every request.
+	Token tools.Secret
+}
+
+// Validate checks configuration values.
+func (c *AdminHTTPConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	_, _, err := net.SplitHostPort(c.Address)
+	v.Check(err == nil, "address", "must be host:port, got %q", c.Address)
+	v.Required("token", c.Token.Value())
+}
+
+// AdminHTTPServer serves HTTP endpoints for administrators, e.g. changing log levels.
+// All endpoints require the token from config.
+type AdminHTTPServer struct {
+	Config              *AdminHTTPConfig
+	logger              *zap.Logger
+	mux                 *http.ServeMux
+	server              *http.Server
+	ErrorWrapperCreator tools.ErrorWrapperCreator
+}
+
+// NewAdminHTTPServer creates a new [AdminHTTPServer] with /log-levels and /circuit-breakers endpoints
+// and starts it if it is enabled.
+// Using for configuring with wire.
+func NewAdminHTTPServer(
+	config *AdminHTTPConfig,
+	logger *zap.Logger,
+	loggerLevels *LoggerLevels,
+	errorWrapperCreator tools.ErrorWrapperCreator,
+	circuitBreakers *tools.CircuitBreakers,
+) (*AdminHTTPServer, func(), error) {
+	s := &AdminHTTPServer{
+		Config:              config,
+		logger:              logger.Named("AdminHTTPServer"),
+		mux:                 http.NewServeMux(),
+		ErrorWrapperCreator: errorWrapperCreator.AppendToPrefix("AdminHTTPServer"),
+	}
+
+	ew := tools.GetErrorWrapper("NewAdminHTTPServer")
+
+	s.Handle("/log-levels", NewLoggerLevelsHTTPHandler(loggerLevels))
+	s.Handle("/circuit-breakers", NewCircuitBreakersHTTPHandler(circuitBreakers))
+
+	if !config.IsEnabled {
+		return s, func() {}, nil
+	}
+
+	listener, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return nil, nil, ew(err)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Admin HTTP server stopped", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("Admin HTTP server started", zap.String("address", listener.Addr().String()))
+
+	return s, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), adminHTTPShutdownTimeout)
+		defer cancel()
+
+		if err := s.server.Shutdown(ctx); err != nil {
+			s.logger.Error("Error while shutting down admin HTTP server", zap.Error(err))
+		}
+	}, nil
+}
+
+// Handle registers handler for pattern, requests without valid token are rejected with 401.
+func (s *AdminHTTPServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.authorize(handler))
+}
+
+// ServeHTTP implements [http.Handler], useful for tests and for mounting endpoints into another server.
+func (s *AdminHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *AdminHTTPServer) authorize(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		expected := s.Config.Token.Value()
+
+		if !found || expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// LoggerLevelRequest is a body of POST request to the handler of [NewLoggerLevelsHTTPHandler].
+type LoggerLevelRequest struct {
+	Sink  string `json:"sink"`
+	Level string `json:"level"`
+	// RevertAfter is a duration like "10m", empty means the level is not reverted.
+	RevertAfter string `json:"revert_after"`
+}
+
+// NewLoggerLevelsHTTPHandler returns handler which reads and changes levels of logger sinks:
+//   - GET returns JSON list of [LoggerSinkLevel]
+//   - POST accepts JSON [LoggerLevelRequest], changes the level and returns the list
+func NewLoggerLevelsHTTPHandler(levels *LoggerLevels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			request := LoggerLevelRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			var revertAfter time.Duration
+
+			if request.RevertAfter != "" {
+				var err error
+
+				revertAfter, err = time.ParseDuration(request.RevertAfter)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			if err := levels.SetLevel(request.Sink, request.Level, revertAfter); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(levels.GetLevels())
+	})
+}
+
+// NewCircuitBreakersHTTPHandler returns handler which responds to GET with JSON list of [tools.CircuitBreakerStats].
+func NewCircuitBreakersHTTPHandler(circuitBreakers *tools.CircuitBreakers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(circuitBreakers.Stats())
+	})
+}
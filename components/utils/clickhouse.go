@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	clickhousego "github.com/ClickHouse/clickhouse-go/v2"
 	"go.uber.org/zap"
 	"gorm.io/driver/clickhouse"
 	"gorm.io/gorm"
@@ -18,10 +22,13 @@ import (
 )
 
 type ClickHouseConfig struct {
+	IsEnabled bool
+	// DSN is a full connection URL, it takes precedence over Host, Port, User, Password and Database.
+	DSN                tools.Secret
 	Host               string
 	Port               int
 	User               string
-	Password           string
+	Password           tools.Secret
 	Database           string
 	IsNeedToRecreate   bool
 	AutoMigrate        bool
@@ -31,6 +38,51 @@ type ClickHouseConfig struct {
 	MaxIdleConns       int
 	MaxOpenConns       int
 	IsDebug            bool
+	// ConnectRetry is a policy of retrying the first connection, nil means a single attempt.
+	ConnectRetry *tools.RetryConfig
+	// IsRecreateConfirmed is set after IsNeedToRecreate is confirmed, see [tools.ConfirmDestructiveAction].
+	// Migrate refuses to drop tables without it.
+	IsRecreateConfirmed bool
+}
+
+// Validate checks configuration values.
+func (c *ClickHouseConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	if c.DSN != "" {
+		_, err := clickhousego.ParseDSN(c.DSN.Value())
+		v.Check(err == nil, "dsn", "invalid connection string: %s", maskedError(err))
+	} else {
+		v.Required("host", c.Host)
+		v.Port("port", c.Port)
+		v.Required("user", c.User)
+		v.Required("database", c.Database)
+	}
+
+	v.NotNegative("conn_max_lifetime", c.ConnMaxLifetime)
+	v.NotNegative("conn_max_idle_time", c.ConnMaxIdleTime)
+	v.NotNegative("max_idle_conns", int64(c.MaxIdleConns))
+	v.NotNegative("max_open_conns", int64(c.MaxOpenConns))
+
+	if c.ConnectRetry != nil {
+		c.ConnectRetry.Validate(v.Section("connect_retry"))
+	}
+}
+
+// GetDatabase returns name of the database from DSN or Database.
+func (c *ClickHouseConfig) GetDatabase() string {
+	if c.DSN == "" {
+		return c.Database
+	}
+
+	parsed, err := clickhousego.ParseDSN(c.DSN.Value())
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Auth.Database
 }
 
 // ClickHouse manipulates connection to ClickHouse database.
@@ -56,7 +108,19 @@ func NewClickHouse(
 
 	ew := tools.GetErrorWrapper("NewClickHouse")
 
-	_, err := c.GetConnection()
+	if !config.IsEnabled {
+		c.logger.Info("ClickHouse is disabled")
+		return c, func() {}, nil
+	}
+
+	// the database may start later than the application, e.g. in docker compose
+	err := tools.Retry(context.Background(), tools.RetryOptions{
+		Config: config.ConnectRetry,
+		Logger: c.logger.Named("Connect"),
+	}, func(context.Context) error {
+		_, err := c.GetConnection()
+		return err
+	})
 	if err != nil {
 		return nil, nil, ew(err)
 	}
@@ -74,18 +138,39 @@ func NewClickHouse(
 	}, nil
 }
 
+// IsEnabled reports whether ClickHouse is enabled in config.
+// Methods of disabled component return [tools.ErrComponentDisabled].
+func (c *ClickHouse) IsEnabled() bool {
+	return c.Config.IsEnabled
+}
+
 // GetConnectionString returns formated connection string.
+// Returns DSN from config as is if it is set.
 func (c *ClickHouse) GetConnectionString() string {
-	hostAndPort := net.JoinHostPort(
-		c.Config.Host,
-		strconv.Itoa(c.Config.Port),
-	)
-
-	return fmt.Sprintf("tcp://%s/%s?username=%s",
-		hostAndPort,
-		c.Config.Database,
-		c.Config.User,
-	)
+	if c.Config.DSN != "" {
+		return c.Config.DSN.Value()
+	}
+
+	query := url.Values{}
+	query.Set("username", c.Config.User)
+
+	if c.Config.Password != "" {
+		query.Set("password", c.Config.Password.Value())
+	}
+
+	connectionURL := url.URL{
+		Scheme:   "tcp",
+		Host:     net.JoinHostPort(c.Config.Host, strconv.Itoa(c.Config.Port)),
+		Path:     "/" + c.Config.Database,
+		RawQuery: query.Encode(),
+	}
+
+	return connectionURL.String()
+}
+
+// GetMaskedConnectionString returns connection string with masked password, safe for logging.
+func (c *ClickHouse) GetMaskedConnectionString() string {
+	return tools.MaskConnectionStringPassword(c.GetConnectionString())
 }
 
 // GetConnection create connection to DB with caching.
@@ -96,17 +181,19 @@ func (c *ClickHouse) GetConnection() (*gorm.DB, error) {
 	ew := c.ErrorWrapperCreator.GetMethodWrapper("GetConnection")
 	logger := c.logger.Named("GetConnection")
 
+	if !c.Config.IsEnabled {
+		return nil, ew(tools.NewErrComponentDisabled("clickhouse"))
+	}
+
 	if c.db != nil {
 		return c.db, nil
 	}
 
-	dsn := c.GetConnectionString()
-
-	logger.Info("dsn", zap.String("dsn", dsn))
+	logger.Info("dsn", zap.String("dsn", c.GetMaskedConnectionString()))
 
-	db, err := gorm.Open(clickhouse.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(clickhouse.Open(c.GetConnectionString()), &gorm.Config{Logger: NewGormLogger(c.logger.Named("Gorm"))})
 	if err != nil {
-		return nil, ew(err)
+		return nil, ew(ClassifyClickHouseError(err))
 	}
 
 	if c.Config.IsDebug {
@@ -130,6 +217,7 @@ func (c *ClickHouse) GetConnection() (*gorm.DB, error) {
 
 // Migrate models to ClickHouse.
 // Depends on Clickhouse.AutoMigrate parameter of [cfg.Config].
+// Tables are dropped only if IsNeedToRecreate is confirmed, see [ClickHouseConfig.IsRecreateConfirmed].
 func (c *ClickHouse) Migrate(models []interface{}) error {
 	ew := c.ErrorWrapperCreator.GetMethodWrapper("Migrate")
 	logger := c.logger.Named("Migrate")
@@ -139,6 +227,13 @@ func (c *ClickHouse) Migrate(models []interface{}) error {
 		return nil
 	}
 
+	if c.Config.IsNeedToRecreate && !c.Config.IsRecreateConfirmed {
+		err := fmt.Errorf("%w: recreate tables of clickhouse database", tools.ErrDestructiveActionNotConfirmed)
+		logger.Error("Recreation of tables is not confirmed", zap.Error(err))
+
+		return ew(err)
+	}
+
 	db, err := c.GetConnection()
 	if err != nil {
 		logger.Error("Failed to connect to database", zap.Error(err))
@@ -175,13 +270,13 @@ func (c *ClickHouse) Migrate(models []interface{}) error {
 			err := db.Migrator().DropTable(entity.Model)
 			if err != nil {
 				logger.Error("Failed to drop table", zap.Error(err))
-				return ew(err)
+				return ew(ClassifyClickHouseError(err))
 			}
 		}
 
 		if err := db.Set("gorm:table_options", entity.Options).AutoMigrate(entity.Model); err != nil {
 			logger.Error("Failed to migrate table", zap.Error(err))
-			return ew(err)
+			return ew(ClassifyClickHouseError(err))
 		}
 	}
 
@@ -313,3 +408,49 @@ type TableMigrateEntityClickhouse struct {
 	Options string
 	Model   interface{}
 }
+
+// clickHouseErrorCodes maps codes of ClickHouse exceptions to categories, other exceptions are permanent.
+var clickHouseErrorCodes = map[int32]tools.ErrorCategory{
+	3:   tools.ErrorCategoryRetryable,    // UNEXPECTED_END_OF_FILE
+	159: tools.ErrorCategoryRetryable,    // TIMEOUT_EXCEEDED
+	202: tools.ErrorCategoryRetryable,    // TOO_MANY_SIMULTANEOUS_QUERIES
+	209: tools.ErrorCategoryRetryable,    // SOCKET_TIMEOUT
+	210: tools.ErrorCategoryRetryable,    // NETWORK_ERROR
+	241: tools.ErrorCategoryRetryable,    // MEMORY_LIMIT_EXCEEDED
+	252: tools.ErrorCategoryRetryable,    // TOO_MANY_PARTS
+	60:  tools.ErrorCategoryNotFound,     // UNKNOWN_TABLE
+	81:  tools.ErrorCategoryNotFound,     // UNKNOWN_DATABASE
+	192: tools.ErrorCategoryForbidden,    // UNKNOWN_USER
+	193: tools.ErrorCategoryForbidden,    // WRONG_PASSWORD
+	497: tools.ErrorCategoryForbidden,    // ACCESS_DENIED
+	516: tools.ErrorCategoryForbidden,    // AUTHENTICATION_FAILED
+	6:   tools.ErrorCategoryInvalidInput, // CANNOT_PARSE_TEXT
+	27:  tools.ErrorCategoryInvalidInput, // CANNOT_PARSE_INPUT_ASSERTION_FAILED
+	53:  tools.ErrorCategoryInvalidInput, // TYPE_MISMATCH
+	62:  tools.ErrorCategoryInvalidInput, // SYNTAX_ERROR
+}
+
+// ClassifyClickHouseError classifies errors of clickhouse-go, see [tools.ClassifyError].
+// The code is "clickhouse.<exception code>" for server exceptions.
+func ClassifyClickHouseError(err error) error {
+	var classifiedError *tools.ClassifiedError
+	if err == nil || errors.As(err, &classifiedError) {
+		return err
+	}
+
+	var exception *clickhousego.Exception
+	if errors.As(err, &exception) {
+		category, ok := clickHouseErrorCodes[exception.Code]
+		if !ok {
+			category = tools.ErrorCategoryPermanent
+		}
+
+		return tools.ClassifyError(err, category, fmt.Sprintf("clickhouse.%d", exception.Code), "")
+	}
+
+	if tools.IsRetryableError(err) {
+		return tools.ClassifyError(err, tools.ErrorCategoryRetryable, "clickhouse.connection", "")
+	}
+
+	return err
+}
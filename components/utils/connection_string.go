@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// maskedError returns message of err with masked passwords of connection strings.
+// Parsers of connection strings may include the whole string into the error.
+func maskedError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return tools.MaskConnectionStringPassword(err.Error())
+}
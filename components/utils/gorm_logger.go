@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// gormSlowQueryThreshold is a duration after which a query is logged as slow, the same as in gorm.
+const gormSlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger writes gorm messages and queries to zap logger.
+// The correlation ID of the query context is added to entries, see [tools.LoggerWithCorrelationID],
+// so queries are linked with the update or message they are made for.
+type GormLogger struct {
+	logger *zap.Logger
+	level  gormlogger.LogLevel
+}
+
+// NewGormLogger creates a [GormLogger], which logs failed and slow queries.
+// All queries are logged after [gorm.DB.Debug].
+func NewGormLogger(logger *zap.Logger) *GormLogger {
+	return &GormLogger{
+		logger: logger.WithOptions(zap.AddCallerSkip(1)),
+		level:  gormlogger.Warn,
+	}
+}
+
+// LogMode implements [gormlogger.Interface].
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{logger: l.logger, level: level}
+}
+
+// Info implements [gormlogger.Interface].
+func (l *GormLogger) Info(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		tools.LoggerWithCorrelationID(ctx, l.logger).Info(fmt.Sprintf(message, data...))
+	}
+}
+
+// Warn implements [gormlogger.Interface].
+func (l *GormLogger) Warn(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		tools.LoggerWithCorrelationID(ctx, l.logger).Warn(fmt.Sprintf(message, data...))
+	}
+}
+
+// Error implements [gormlogger.Interface].
+func (l *GormLogger) Error(ctx context.Context, message string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		tools.LoggerWithCorrelationID(ctx, l.logger).Error(fmt.Sprintf(message, data...))
+	}
+}
+
+// Trace implements [gormlogger.Interface].
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	isFailed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	isSlow := elapsed > gormSlowQueryThreshold
+
+	if !(isFailed && l.level >= gormlogger.Error) && !(isSlow && l.level >= gormlogger.Warn) && l.level < gormlogger.Info {
+		return
+	}
+
+	sql, rows := fc()
+	logger := tools.LoggerWithCorrelationID(ctx, l.logger)
+	fields := []zap.Field{zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed)}
+
+	switch {
+	case isFailed && l.level >= gormlogger.Error:
+		logger.Error("Query failed", append(fields, zap.Error(err))...)
+	case isSlow && l.level >= gormlogger.Warn:
+		logger.Warn("Slow query", fields...)
+	default:
+		logger.Info("Query", fields...)
+	}
+}
@@ -1,14 +1,20 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
 )
@@ -23,25 +29,111 @@ func GetZapLogger() *zap.Logger {
 
 type LoggerConsoleConfig struct {
 	Level string
-}
-
-type LoggerRotationConfig struct {
-	MaxSize    int
-	MaxAge     int
-	MaxBackups int
-	Localtime  bool
-	Compress   bool
+	// Output is one of [LoggerOutputNames], default is stdout.
+	Output string
+	// Format may be nil, default encoder is console.
+	Format *LoggerFormatConfig
 }
 
 type LoggerFileConfig struct {
+	// Name is required for [LoggerConfig.Files], the sink is named by [LoggerFileSinkName].
+	Name     string
 	Level    string
 	Path     string
 	Rotation *LoggerRotationConfig
+	// Format may be nil, default encoder is json.
+	Format *LoggerFormatConfig
 }
 
 type LoggerConfig struct {
 	Console *LoggerConsoleConfig
 	File    *LoggerFileConfig
+	// Files are additional file sinks, e.g. errors.log with error level next to app.log.
+	Files      []*LoggerFileConfig
+	ClickHouse *LoggerClickHouseConfig
+	// Telegram forwards entries to admins, see [TelegramLogSink].
+	Telegram *LoggerTelegramConfig
+	// Syslog sends entries to a syslog server, see [SyslogLogSink].
+	Syslog *LoggerSyslogConfig
+	// Overrides contains levels by logger name prefix, e.g. "ClickHouse" -> "debug".
+	// Levels of sinks are ignored for matching loggers.
+	Overrides map[string]string
+	// Redaction extends names of fields and patterns masked in all sinks, may be nil.
+	Redaction *LoggerRedactionConfig
+}
+
+// Validate checks configuration values.
+func (c *LoggerConfig) Validate(v *tools.ConfigValidator) {
+	if c.Console != nil {
+		consoleValidator := v.Section("console")
+		validateZapLevel(consoleValidator, c.Console.Level)
+
+		_, err := getConsoleWriter(c.Console.Output)
+		consoleValidator.Check(err == nil, "output", "%s", err)
+
+		if c.Console.Format != nil {
+			c.Console.Format.Validate(consoleValidator.Section("format"))
+		}
+	}
+
+	if c.File != nil {
+		c.File.Validate(v.Section("file"))
+	}
+
+	names := map[string]bool{}
+
+	for i, file := range c.Files {
+		fileValidator := v.Section(fmt.Sprintf("files[%d]", i))
+		file.Validate(fileValidator)
+		fileValidator.Check(loggerFileNameRegexp.MatchString(file.Name), "name",
+			"must match %s", loggerFileNameRegexp,
+		)
+		fileValidator.Check(!names[file.Name], "name", "duplicated name %q", file.Name)
+
+		names[file.Name] = true
+	}
+
+	if c.ClickHouse != nil {
+		c.ClickHouse.Validate(v.Section("clickhouse"))
+	}
+
+	if c.Telegram != nil {
+		c.Telegram.Validate(v.Section("telegram"))
+	}
+
+	if c.Syslog != nil {
+		c.Syslog.Validate(v.Section("syslog"))
+	}
+
+	overridesValidator := v.Section("overrides")
+	for _, name := range tools.SortMapKeys(c.Overrides) {
+		_, err := ParseZapLevel(c.Overrides[name])
+		overridesValidator.Check(err == nil, name, "%s", err)
+		overridesValidator.Check(name != "", name, "logger name must not be empty")
+	}
+
+	if c.Redaction != nil {
+		c.Redaction.Validate(v.Section("redaction"))
+	}
+}
+
+// Validate checks configuration values.
+func (c *LoggerFileConfig) Validate(v *tools.ConfigValidator) {
+	validateZapLevel(v, c.Level)
+	v.Required("path", c.Path)
+
+	if c.Rotation != nil {
+		c.Rotation.Validate(v.Section("rotation"))
+	}
+
+	if c.Format != nil {
+		c.Format.Validate(v.Section("format"))
+	}
+}
+
+func validateZapLevel(v *tools.ConfigValidator, level string) {
+	_, err := ParseZapLevel(level)
+	v.Check(err == nil, "level", "%s", err)
 }
 
 func NewProductionEncoderConfig() zapcore.EncoderConfig {
@@ -52,94 +144,583 @@ func NewProductionEncoderConfig() zapcore.EncoderConfig {
 	return cfg
 }
 
-func NewConsoleZapCore(config *LoggerConsoleConfig) zapcore.Core {
-	level := ConvertZapLevel(config.Level)
+// Names of logger sinks.
+const (
+	LoggerSinkConsole    = "console"
+	LoggerSinkFile       = "file"
+	LoggerSinkClickHouse = "clickhouse"
+	LoggerSinkTelegram   = "telegram"
+	LoggerSinkSyslog     = "syslog"
+)
 
-	return zapcore.NewCore(
-		zapcore.NewConsoleEncoder(NewProductionEncoderConfig()),
-		zapcore.AddSync(os.Stdout),
-		zap.NewAtomicLevelAt(level),
-	)
+var loggerFileNameRegexp = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// LoggerFileSinkName returns name of the sink of [LoggerConfig.Files] item, e.g. "file.errors".
+func LoggerFileSinkName(name string) string {
+	return LoggerSinkFile + "." + name
+}
+
+// ErrUnknownLoggerSink is returned when a logger sink does not exist or is disabled.
+var ErrUnknownLoggerSink = errors.New("unknown logger sink")
+
+// LoggerLevels contains levels of logger sinks and levels overridden by logger names.
+// They can be changed at runtime by [LoggerLevels.SetLevel] and [LoggerLevels.SetOverrides],
+// which are safe for concurrent use.
+type LoggerLevels struct {
+	Console    zap.AtomicLevel
+	File       zap.AtomicLevel
+	ClickHouse zap.AtomicLevel
+	Telegram   zap.AtomicLevel
+	Syslog     zap.AtomicLevel
+	// Files contains levels of [LoggerConfig.Files] by sink names, see [LoggerFileSinkName].
+	Files     map[string]zap.AtomicLevel
+	sinks     []string
+	mutex     sync.Mutex
+	reverts   map[string]*loggerLevelRevert
+	overrides atomic.Pointer[loggerOverrides]
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+}
+
+// loggerLevelRevert is a pending restoration of a sink level.
+type loggerLevelRevert struct {
+	stop  func() bool
+	level zapcore.Level
+	at    time.Time
+}
+
+// LoggerSinkLevel is a current level of a logger sink.
+type LoggerSinkLevel struct {
+	Sink  string `json:"sink"`
+	Level string `json:"level"`
+	// RevertAt is set if the level is changed temporarily.
+	RevertAt *time.Time `json:"revert_at,omitempty"`
 }
 
-func NewFileZapCore(config *LoggerFileConfig) (zapcore.Core, error) {
-	level := ConvertZapLevel(config.Level)
-	ew := tools.GetErrorWrapper("NewUserManager")
+// NewLoggerLevels creates levels of logger sinks from config.
+// Only enabled sinks can be changed by [LoggerLevels.SetLevel].
+// Returns [ErrUnknownLogLevel] if any level in config is invalid.
+// Using for configuring with wire.
+func NewLoggerLevels(config *LoggerConfig) (*LoggerLevels, error) {
+	return NewLoggerLevelsWithClock(config, nil, nil)
+}
+
+// NewLoggerLevelsWithClock is [NewLoggerLevels] which uses now and afterFunc for temporary changes of levels.
+// afterFunc calls f after d and returns a function cancelling the call, like [time.AfterFunc] and [time.Timer.Stop].
+// nil means [time.Now] and [time.AfterFunc].
+func NewLoggerLevelsWithClock(
+	config *LoggerConfig,
+	now func() time.Time,
+	afterFunc func(d time.Duration, f func()) (stop func() bool),
+) (*LoggerLevels, error) {
+	ew := tools.GetErrorWrapper("NewLoggerLevels")
+
+	if now == nil {
+		now = time.Now
+	}
+
+	if afterFunc == nil {
+		afterFunc = func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		}
+	}
+
+	levels := &LoggerLevels{
+		Console:    zap.NewAtomicLevel(),
+		File:       zap.NewAtomicLevel(),
+		ClickHouse: zap.NewAtomicLevel(),
+		Telegram:   zap.NewAtomicLevel(),
+		Syslog:     zap.NewAtomicLevel(),
+		Files:      map[string]zap.AtomicLevel{},
+		sinks:      []string{},
+		reverts:    map[string]*loggerLevelRevert{},
+		now:        now,
+		afterFunc:  afterFunc,
+	}
+
+	if config.Console != nil {
+		level, err := ParseZapLevel(config.Console.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		levels.Console.SetLevel(level)
+		levels.sinks = append(levels.sinks, LoggerSinkConsole)
+	}
+
+	if config.File != nil {
+		level, err := ParseZapLevel(config.File.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		levels.File.SetLevel(level)
+		levels.sinks = append(levels.sinks, LoggerSinkFile)
+	}
+
+	for _, file := range config.Files {
+		level, err := ParseZapLevel(file.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		sink := LoggerFileSinkName(file.Name)
+		levels.Files[sink] = zap.NewAtomicLevelAt(level)
+		levels.sinks = append(levels.sinks, sink)
+	}
+
+	if config.ClickHouse != nil {
+		level, err := ParseZapLevel(config.ClickHouse.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		levels.ClickHouse.SetLevel(level)
+		levels.sinks = append(levels.sinks, LoggerSinkClickHouse)
+	}
+
+	if config.Telegram != nil {
+		level, err := ParseZapLevel(config.Telegram.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		levels.Telegram.SetLevel(level)
+		levels.sinks = append(levels.sinks, LoggerSinkTelegram)
+	}
+
+	if config.Syslog != nil {
+		level, err := ParseZapLevel(config.Syslog.Level)
+		if err != nil {
+			return nil, ew(err)
+		}
 
-	dir := filepath.Dir(config.Path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+		levels.Syslog.SetLevel(level)
+		levels.sinks = append(levels.sinks, LoggerSinkSyslog)
+	}
+
+	if err := levels.SetOverrides(config.Overrides); err != nil {
 		return nil, ew(err)
 	}
 
-	var writer io.Writer
+	return levels, nil
+}
+
+// GetOverrides returns levels overridden by logger name prefixes.
+func (l *LoggerLevels) GetOverrides() map[string]string {
+	return l.overrides.Load().toMap()
+}
+
+// SetOverrides replaces all levels overridden by logger name prefixes.
+// The most specific prefix is used, e.g. for logger "S3Manager.GetClient"
+// override "S3Manager.GetClient" is preferred to "S3Manager".
+func (l *LoggerLevels) SetOverrides(overrides map[string]string) error {
+	parsed, err := parseLoggerOverrides(overrides)
+	if err != nil {
+		return tools.WrapMethodError(err, "LoggerLevels.SetOverrides")
+	}
+
+	l.overrides.Store(parsed)
+
+	return nil
+}
+
+// wrapSinkCore applies level of the sink and overrides to core, which must accept all levels.
+func (l *LoggerLevels) wrapSinkCore(core zapcore.Core, level zap.AtomicLevel) zapcore.Core {
+	return newLevelFilterCore(core, level, &l.overrides)
+}
+
+// wrapFallbackCore filters core as a fallback of the sink with the level:
+// only entries which the sink drops by its level or overrides are passed, the rest are already written by the sink.
+func (l *LoggerLevels) wrapFallbackCore(core zapcore.Core, level zap.AtomicLevel) zapcore.Core {
+	return newRejectedFilterCore(core, level, &l.overrides)
+}
+
+func (l *LoggerLevels) getAtomicLevel(sink string) (zap.AtomicLevel, error) {
+	if !slices.Contains(l.sinks, sink) {
+		return zap.AtomicLevel{}, fmt.Errorf("%w: %q, expected one of %s",
+			ErrUnknownLoggerSink, sink, strings.Join(l.sinks, ", "),
+		)
+	}
+
+	switch sink {
+	case LoggerSinkConsole:
+		return l.Console, nil
+	case LoggerSinkFile:
+		return l.File, nil
+	case LoggerSinkClickHouse:
+		return l.ClickHouse, nil
+	case LoggerSinkTelegram:
+		return l.Telegram, nil
+	case LoggerSinkSyslog:
+		return l.Syslog, nil
+	default:
+		return l.Files[sink], nil
+	}
+}
+
+// GetLevels returns current levels of enabled sinks.
+func (l *LoggerLevels) GetLevels() []LoggerSinkLevel {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	levels := []LoggerSinkLevel{}
+
+	for _, sink := range l.sinks {
+		atomicLevel, _ := l.getAtomicLevel(sink)
+		sinkLevel := LoggerSinkLevel{Sink: sink, Level: atomicLevel.Level().String()}
+
+		if revert, ok := l.reverts[sink]; ok {
+			revertAt := revert.at
+			sinkLevel.RevertAt = &revertAt
+		}
+
+		levels = append(levels, sinkLevel)
+	}
+
+	return levels
+}
+
+// SetLevel changes level of the sink.
+// If revertAfter is positive, the level which was set before the temporary change is restored after it.
+// A new call cancels pending restoration of the sink, but keeps the level to restore if revertAfter is positive again.
+func (l *LoggerLevels) SetLevel(sink string, level string, revertAfter time.Duration) error {
+	ew := tools.GetErrorWrapper("LoggerLevels.SetLevel")
 
-	writer, err := os.OpenFile(config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	zapLevel, err := ParseZapLevel(level)
 	if err != nil {
-		return nil, ew(err)
+		return ew(err)
 	}
 
-	if config.Rotation != nil {
-		writer = &lumberjack.Logger{
-			Filename:   config.Path,
-			MaxSize:    config.Rotation.MaxSize,
-			MaxAge:     config.Rotation.MaxAge,
-			MaxBackups: config.Rotation.MaxBackups,
-			LocalTime:  config.Rotation.Localtime,
-			Compress:   config.Rotation.Compress,
+	atomicLevel, err := l.getAtomicLevel(sink)
+	if err != nil {
+		return ew(err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	levelToRestore := atomicLevel.Level()
+
+	if revert, ok := l.reverts[sink]; ok {
+		revert.stop()
+		delete(l.reverts, sink)
+
+		levelToRestore = revert.level
+	}
+
+	atomicLevel.SetLevel(zapLevel)
+
+	if revertAfter <= 0 {
+		return nil
+	}
+
+	revert := &loggerLevelRevert{level: levelToRestore, at: l.now().Add(revertAfter)}
+	revert.stop = l.afterFunc(revertAfter, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+
+		if l.reverts[sink] != revert {
+			return
 		}
+
+		atomicLevel.SetLevel(revert.level)
+		delete(l.reverts, sink)
+	})
+	l.reverts[sink] = revert
+
+	return nil
+}
+
+// NewConsoleZapCore creates a core writing to stdout or stderr.
+// Caller and stacktrace are written only if they are added by the logger.
+func NewConsoleZapCore(config *LoggerConsoleConfig, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	ew := tools.GetErrorWrapper("NewConsoleZapCore")
+
+	writer, err := getConsoleWriter(config.Output)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	core, err := newFormattedZapCore(config.Format, LoggerEncoderConsole, writer, level)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return core, nil
+}
+
+// NewFileZapCore creates a core writing to the file, which is rotated if rotation is configured.
+// Caller and stacktrace are written only if they are added by the logger.
+// The returned file must be closed after the core is no longer used.
+func NewFileZapCore(config *LoggerFileConfig, level zapcore.LevelEnabler) (zapcore.Core, *LogFile, error) {
+	ew := tools.GetErrorWrapper("NewFileZapCore")
+
+	file, err := NewLogFile(config.Path, config.Rotation, nil)
+	if err != nil {
+		return nil, nil, ew(err)
+	}
+
+	core, err := newFormattedZapCore(config.Format, LoggerEncoderJSON, file, level)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, ew(err)
 	}
 
-	return zapcore.NewCore(
-		zapcore.NewJSONEncoder(NewProductionEncoderConfig()),
-		zapcore.AddSync(writer),
-		zap.NewAtomicLevelAt(level),
-	), nil
+	return core, file, nil
 }
 
 // NewLogger returns a new Logger component.
-func NewLogger(config *LoggerConfig) (*zap.Logger, func(), error) {
-	ew := tools.GetErrorWrapper("NewUserManager")
+// Levels of sinks and overrides by logger names are taken from levels, so they can be changed at runtime.
+// ClickHouse sink falls back to the file sink if ClickHouse is unavailable.
+// Telegram sink sends entries through telegramSender, failures of sending are written to the file sink.
+// Syslog sink falls back to the file sink while the syslog server is unavailable.
+// Entries are written to the file sink as a fallback only if its level and overrides drop them,
+// the rest are already written by the file sink itself. Messages of sinks about failures are always written.
+// Sensitive fields and credentials are masked in every sink, see [LogRedactor].
+// Details of errors wrapped by [tools.ErrorWrapperCreator] are written next to them, see [NewErrorDetailsCore].
+// Log files are rotated on SIGHUP, see [LogFile.Rotate].
+func NewLogger(
+	config *LoggerConfig,
+	levels *LoggerLevels,
+	telegramSender *TelegramLogSenderProxy,
+) (*zap.Logger, func(), error) {
+	ew := tools.GetErrorWrapper("NewLogger")
 	cores := []zapcore.Core{}
+	closers := []func(){}
+
+	redactor, err := NewLogRedactor(config.Redaction)
+	if err != nil {
+		return nil, nil, ew(err)
+	}
+
+	// details of errors are added before redaction, so they are redacted too
+	wrapCore := func(core zapcore.Core) zapcore.Core {
+		return NewErrorDetailsCore(redactor.WrapCore(core))
+	}
+
+	// the logger adds stacktrace for the lowest level required by any sink, cores remove unneeded ones
+	stacktraceLevel := zapcore.InvalidLevel
+	formats := []*LoggerFormatConfig{}
 
 	if config.Console != nil {
-		cores = append(cores, NewConsoleZapCore(config.Console))
+		core, err := NewConsoleZapCore(config.Console, zapcore.DebugLevel)
+		if err != nil {
+			return nil, nil, ew(err)
+		}
+
+		formats = append(formats, config.Console.Format)
+		cores = append(cores, levels.wrapSinkCore(wrapCore(core), levels.Console))
+	}
+
+	// fallbackCore receives entries which other sinks fail to send,
+	// only entries dropped by the level of the file sink are passed, so each entry is written to the file once
+	var fallbackCore zapcore.Core
+
+	files := []*LogFile{}
+	closeFiles := func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
 	}
 
 	if config.File != nil {
-		core, err := NewFileZapCore(config.File)
+		core, file, err := NewFileZapCore(config.File, zapcore.DebugLevel)
+		if err != nil {
+			return nil, nil, ew(err)
+		}
+
+		files = append(files, file)
+
+		fileCore := wrapCore(core)
+		fallbackCore = levels.wrapFallbackCore(fileCore, levels.File)
+		formats = append(formats, config.File.Format)
+		cores = append(cores, levels.wrapSinkCore(fileCore, levels.File))
+	}
+
+	for _, fileConfig := range config.Files {
+		core, file, err := NewFileZapCore(fileConfig, zapcore.DebugLevel)
+		if err != nil {
+			closeFiles()
+			return nil, nil, ew(err)
+		}
+
+		files = append(files, file)
+		formats = append(formats, fileConfig.Format)
+		cores = append(cores, levels.wrapSinkCore(wrapCore(core), levels.Files[LoggerFileSinkName(fileConfig.Name)]))
+	}
+
+	if config.Syslog != nil {
+		formats = append(formats, config.Syslog.Format)
+	}
+
+	for _, format := range formats {
+		_, formatStacktraceLevel, err := format.getEntryLevels()
+		if err != nil {
+			closeFiles()
+			return nil, nil, ew(err)
+		}
+
+		stacktraceLevel = min(stacktraceLevel, formatStacktraceLevel)
+	}
+
+	if config.ClickHouse != nil {
+		writer, err := NewClickHouseLogWriter(config.ClickHouse.Connection, config.ClickHouse.Table)
+		if err != nil {
+			closeFiles()
+			return nil, nil, ew(err)
+		}
+
+		sink := NewClickHouseLogSink(writer, fallbackCore, ClickHouseLogSinkOptions{
+			FlushInterval: config.ClickHouse.FlushInterval,
+			BatchSize:     config.ClickHouse.BatchSize,
+			BufferSize:    config.ClickHouse.BufferSize,
+		})
+		closers = append(closers, sink.Close)
+		cores = append(cores, levels.wrapSinkCore(wrapCore(sink.Core(zapcore.DebugLevel)), levels.ClickHouse))
+	}
+
+	if config.Telegram != nil {
+		sink := NewTelegramLogSink(telegramSender, fallbackCore, TelegramLogSinkOptions{
+			DedupWindow:  config.Telegram.DedupWindow,
+			RateLimit:    config.Telegram.RateLimit,
+			RateInterval: time.Minute,
+			BufferSize:   config.Telegram.BufferSize,
+			RetryAfter:   time.Minute,
+		})
+		closers = append(closers, sink.Close)
+		// the level of the sink is a floor, so overrides for other sinks do not send e.g. debug entries to admins
+		cores = append(cores, levels.wrapSinkCore(wrapCore(sink.Core(levels.Telegram)), levels.Telegram))
+	}
+
+	if config.Syslog != nil {
+		sink, err := NewSyslogLogSink(SyslogLogSinkOptions{
+			Network:           config.Syslog.Network,
+			Address:           config.Syslog.Address,
+			Facility:          config.Syslog.Facility,
+			AppName:           config.Syslog.AppName,
+			Hostname:          config.Syslog.Hostname,
+			BufferSize:        config.Syslog.BufferSize,
+			ReconnectInterval: config.Syslog.ReconnectInterval,
+		}, fallbackCore)
+		if err != nil {
+			closeSinks(closers)
+			closeFiles()
+			return nil, nil, ew(err)
+		}
+
+		closers = append(closers, sink.Close)
+
+		core, err := sink.Core(zapcore.DebugLevel, config.Syslog.Format)
 		if err != nil {
+			closeSinks(closers)
+			closeFiles()
 			return nil, nil, ew(err)
 		}
 
-		cores = append(cores, core)
+		cores = append(cores, levels.wrapSinkCore(wrapCore(core), levels.Syslog))
 	}
 
-	logger := zap.New(zapcore.NewTee(cores...))
+	logger := zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(stacktraceLevel))
+
+	if len(files) > 0 {
+		closers = append(closers, rotateLogFilesOnSignal(files, logger.Named("Logger")), closeFiles)
+	}
 
 	//nolint:forbidigo
 	return logger,
-		func() { err := logger.Sync(); fmt.Println("Logger sync error:", err) },
+		func() {
+			err := logger.Sync()
+			fmt.Println("Logger sync error:", err)
+
+			closeSinks(closers)
+		},
 		nil
 }
 
-// ConvertZapLevel converts a string level to a zapcore.Level.
-// e.g. "debug" -> zapcore.DebugLevel.
-// If level is invalid, it returns zapcore.InfoLevel.
-func ConvertZapLevel(level string) zapcore.Level {
-	logLevel := zapcore.InfoLevel
+// writeCheckedEntry writes the entry to core only if core accepts it, e.g. by level.
+// Sinks use it for entries which they fail to send, since [zapcore.Core.Write] does not check the entry.
+func writeCheckedEntry(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) {
+	if checked := core.Check(entry, nil); checked != nil {
+		checked.Write(fields...)
+	}
+}
+
+func closeSinks(closers []func()) {
+	for _, closer := range closers {
+		closer()
+	}
+}
+
+// rotateLogFilesOnSignal rotates the files on SIGHUP until the returned function is called.
+func rotateLogFilesOnSignal(files []*LogFile, logger *zap.Logger) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer close(stopped)
+
+		for {
+			select {
+			case <-signals:
+				for _, file := range files {
+					if err := file.Rotate(); err != nil {
+						logger.Error("Failed to rotate log file", zap.Error(err))
+					}
+				}
+
+				logger.Info("Log files rotated")
+			case <-done:
+				return
+			}
+		}
+	}()
 
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
+
+// ErrUnknownLogLevel is returned when a log level string is not supported.
+var ErrUnknownLogLevel = errors.New("unknown log level")
+
+// ZapLevelNames contains all levels supported by [ParseZapLevel].
+var ZapLevelNames = []string{"debug", "info", "warn", "error", "panic"}
+
+// ParseZapLevel converts a string level to a zapcore.Level.
+// e.g. "debug" -> zapcore.DebugLevel.
+// Returns [ErrUnknownLogLevel] if level is not one of [ZapLevelNames].
+func ParseZapLevel(level string) (zapcore.Level, error) {
 	switch level {
 	case "debug":
-		logLevel = zap.DebugLevel
+		return zap.DebugLevel, nil
 	case "info":
-		logLevel = zap.InfoLevel
+		return zap.InfoLevel, nil
 	case "warn":
-		logLevel = zap.WarnLevel
+		return zap.WarnLevel, nil
 	case "error":
-		logLevel = zap.ErrorLevel
+		return zap.ErrorLevel, nil
 	case "panic":
-		logLevel = zap.PanicLevel
+		return zap.PanicLevel, nil
 	}
 
+	return zapcore.InfoLevel, fmt.Errorf("%w: %q, expected one of %s",
+		ErrUnknownLogLevel, level, strings.Join(ZapLevelNames, ", "),
+	)
+}
+
+// ConvertZapLevel converts a string level to a zapcore.Level.
+// e.g. "debug" -> zapcore.DebugLevel.
+// If level is invalid, it returns zapcore.InfoLevel.
+//
+// Deprecated: invalid levels are silently ignored, use [ParseZapLevel].
+func ConvertZapLevel(level string) zapcore.Level {
+	logLevel, _ := ParseZapLevel(level)
+
 	return logLevel
 }
@@ -0,0 +1,424 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// clickHouseLogSyncTimeout limits waiting for flush in [ClickHouseLogSink.Sync] and [ClickHouseLogSink.Close].
+const clickHouseLogSyncTimeout = 10 * time.Second
+
+// ErrInvalidClickHouseLogTable is returned when a name of the log table is not a valid identifier.
+var ErrInvalidClickHouseLogTable = errors.New("invalid clickhouse log table name")
+
+var clickHouseLogTableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type LoggerClickHouseConfig struct {
+	Level         string
+	Table         string
+	FlushInterval time.Duration
+	BatchSize     int
+	BufferSize    int
+	// Connection is used for a separate connection, so the sink does not depend on [ClickHouse] component.
+	Connection *ClickHouseConfig
+}
+
+// Validate checks configuration values.
+func (c *LoggerClickHouseConfig) Validate(v *tools.ConfigValidator) {
+	validateZapLevel(v, c.Level)
+	v.Check(clickHouseLogTableRegexp.MatchString(c.Table), "table", "must be a table name like logs or db.logs, got %q", c.Table)
+	v.Positive("flush_interval", int64(c.FlushInterval))
+	v.Positive("batch_size", int64(c.BatchSize))
+	v.Positive("buffer_size", int64(c.BufferSize))
+}
+
+// ClickHouseLogEntry is a row of the log table.
+type ClickHouseLogEntry struct {
+	Timestamp time.Time
+	Level     string
+	Logger    string
+	Message   string
+	Caller    string
+	Fields    map[string]string
+
+	// original entry and fields for writing to the fallback core
+	entry     zapcore.Entry
+	zapFields []zapcore.Field
+}
+
+// ClickHouseLogWriter writes batches of log entries.
+// Implementations must not retain entries after returning.
+type ClickHouseLogWriter interface {
+	WriteLogs(entries []ClickHouseLogEntry) error
+}
+
+// ClickHouseLogSinkOptions configures batching of [ClickHouseLogSink].
+type ClickHouseLogSinkOptions struct {
+	FlushInterval time.Duration
+	BatchSize     int
+	// BufferSize is a number of entries waiting for flush, new entries are dropped if it is exceeded.
+	BufferSize int
+}
+
+// ClickHouseLogSink sends log entries to ClickHouse in batches in a background goroutine.
+//
+// Logging never blocks: if the buffer is full, entries are dropped and counted, see [ClickHouseLogSink.Dropped].
+// If a batch cannot be written, its entries are written to the fallback core (e.g. file core) if it is set.
+type ClickHouseLogSink struct {
+	writer       ClickHouseLogWriter
+	fallback     zapcore.Core
+	options      ClickHouseLogSinkOptions
+	entries      chan ClickHouseLogEntry
+	syncRequests chan chan struct{}
+	stop         chan struct{}
+	stopped      chan struct{}
+	stopOnce     sync.Once
+	dropped      atomic.Uint64
+	reported     uint64
+}
+
+// NewClickHouseLogSink creates a new [ClickHouseLogSink] and starts flushing.
+// fallback may be nil, then entries of failed batches are dropped.
+// Entries of failed batches are written to fallback only if it accepts them, messages of the sink are always written.
+// Use [ClickHouseLogSink.Close] to flush remaining entries and stop.
+func NewClickHouseLogSink(
+	writer ClickHouseLogWriter,
+	fallback zapcore.Core,
+	options ClickHouseLogSinkOptions,
+) *ClickHouseLogSink {
+	s := &ClickHouseLogSink{
+		writer:       writer,
+		fallback:     fallback,
+		options:      options,
+		entries:      make(chan ClickHouseLogEntry, options.BufferSize),
+		syncRequests: make(chan chan struct{}),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Core returns [zapcore.Core] which sends entries enabled by level to the sink.
+func (s *ClickHouseLogSink) Core(level zapcore.LevelEnabler) zapcore.Core {
+	return &clickHouseLogCore{LevelEnabler: level, sink: s}
+}
+
+// Dropped returns a number of entries dropped because of buffer overflow or failed writes without fallback.
+func (s *ClickHouseLogSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Sync writes buffered entries and waits for the result.
+func (s *ClickHouseLogSink) Sync() error {
+	done := make(chan struct{})
+
+	select {
+	case s.syncRequests <- done:
+	case <-s.stopped:
+		return nil
+	case <-time.After(clickHouseLogSyncTimeout):
+		return tools.WrapMethodError(context.DeadlineExceeded, "ClickHouseLogSink.Sync")
+	}
+
+	<-done
+
+	return nil
+}
+
+// Close writes buffered entries and stops the sink.
+// Entries logged after closing are dropped when the buffer is full.
+func (s *ClickHouseLogSink) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.stopped:
+	case <-time.After(clickHouseLogSyncTimeout):
+	}
+}
+
+func (s *ClickHouseLogSink) add(entry ClickHouseLogEntry) {
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *ClickHouseLogSink) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ClickHouseLogEntry, 0, s.options.BatchSize)
+
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) >= s.options.BatchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(batch)
+		case done := <-s.syncRequests:
+			batch = s.flush(s.drain(batch))
+			close(done)
+		case <-s.stop:
+			s.flush(s.drain(batch))
+			return
+		}
+	}
+}
+
+// drain moves all buffered entries to batch, flushing full batches.
+func (s *ClickHouseLogSink) drain(batch []ClickHouseLogEntry) []ClickHouseLogEntry {
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) >= s.options.BatchSize {
+				batch = s.flush(batch)
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// flush writes batch and returns it emptied for reuse.
+func (s *ClickHouseLogSink) flush(batch []ClickHouseLogEntry) []ClickHouseLogEntry {
+	if len(batch) > 0 {
+		if err := s.writer.WriteLogs(batch); err != nil {
+			s.writeFallback(batch, err)
+		}
+	}
+
+	s.reportDropped()
+
+	return batch[:0]
+}
+
+func (s *ClickHouseLogSink) writeFallback(batch []ClickHouseLogEntry, err error) {
+	if s.fallback == nil {
+		s.dropped.Add(uint64(len(batch)))
+		return
+	}
+
+	s.writeFallbackMessage(zapcore.ErrorLevel, "Failed to write logs to ClickHouse, writing to fallback",
+		zap.Int("count", len(batch)),
+		zap.String("error", tools.MaskConnectionStringPassword(err.Error())),
+	)
+
+	for _, entry := range batch {
+		writeCheckedEntry(s.fallback, entry.entry, entry.zapFields)
+	}
+}
+
+// reportDropped writes a warning to the fallback core if entries were dropped since the last report.
+func (s *ClickHouseLogSink) reportDropped() {
+	dropped := s.dropped.Load()
+	if dropped == s.reported || s.fallback == nil {
+		return
+	}
+
+	s.writeFallbackMessage(zapcore.WarnLevel, "ClickHouse log entries dropped",
+		zap.Uint64("dropped", dropped-s.reported),
+		zap.Uint64("dropped_total", dropped),
+	)
+
+	s.reported = dropped
+}
+
+// writeFallbackMessage writes a message of the sink to the fallback core without checking,
+// since the message is not passed to other cores of the logger, unlike entries of failed batches.
+func (s *ClickHouseLogSink) writeFallbackMessage(level zapcore.Level, message string, fields ...zapcore.Field) {
+	_ = s.fallback.Write(zapcore.Entry{
+		Level:      level,
+		Time:       time.Now(),
+		LoggerName: "ClickHouseLogSink",
+		Message:    message,
+	}, fields)
+}
+
+// clickHouseLogCore converts entries to [ClickHouseLogEntry] and passes them to the sink.
+type clickHouseLogCore struct {
+	zapcore.LevelEnabler
+	sink   *ClickHouseLogSink
+	fields []zapcore.Field
+}
+
+// With implements [zapcore.Core].
+func (c *clickHouseLogCore) With(fields []zapcore.Field) zapcore.Core {
+	return &clickHouseLogCore{
+		LevelEnabler: c.LevelEnabler,
+		sink:         c.sink,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check implements [zapcore.Core].
+func (c *clickHouseLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *clickHouseLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	allFields := append(append([]zapcore.Field{}, c.fields...), fields...)
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range allFields {
+		field.AddTo(encoder)
+	}
+
+	row := ClickHouseLogEntry{
+		Timestamp: entry.Time,
+		Level:     entry.Level.String(),
+		Logger:    entry.LoggerName,
+		Message:   entry.Message,
+		Fields:    make(map[string]string, len(encoder.Fields)+1),
+		entry:     entry,
+		zapFields: allFields,
+	}
+
+	if entry.Caller.Defined {
+		row.Caller = entry.Caller.TrimmedPath()
+	}
+
+	for key, value := range encoder.Fields {
+		row.Fields[key] = stringifyLogFieldValue(value)
+	}
+
+	if entry.Stack != "" {
+		row.Fields["stacktrace"] = entry.Stack
+	}
+
+	c.sink.add(row)
+
+	return nil
+}
+
+// Sync implements [zapcore.Core].
+func (c *clickHouseLogCore) Sync() error {
+	return c.sink.Sync()
+}
+
+// stringifyLogFieldValue converts a value of encoded field to a string, complex values are encoded as JSON.
+func stringifyLogFieldValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case fmt.Stringer:
+		return typedValue.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(typedValue)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
+
+// clickHouseLogTableWriter writes entries to a ClickHouse table, the table is created if it does not exist.
+type clickHouseLogTableWriter struct {
+	clickHouse     *ClickHouse
+	table          string
+	isTableCreated bool
+}
+
+// NewClickHouseLogWriter creates a [ClickHouseLogWriter] for the table.
+// Connection is opened on the first write, so ClickHouse may be unavailable at startup.
+func NewClickHouseLogWriter(config *ClickHouseConfig, table string) (ClickHouseLogWriter, error) {
+	if !clickHouseLogTableRegexp.MatchString(table) {
+		return nil, tools.WrapMethodError(fmt.Errorf("%w: %q", ErrInvalidClickHouseLogTable, table), "NewClickHouseLogWriter")
+	}
+
+	return &clickHouseLogTableWriter{
+		clickHouse: &ClickHouse{
+			Config: config,
+			// the sink must not log through the logger it is a part of
+			logger:              zap.NewNop(),
+			ErrorWrapperCreator: tools.NewErrorWrapperCreator().AppendToPrefix("ClickHouseLogWriter"),
+		},
+		table: table,
+	}, nil
+}
+
+// WriteLogs implements [ClickHouseLogWriter].
+func (w *clickHouseLogTableWriter) WriteLogs(entries []ClickHouseLogEntry) error {
+	ew := tools.GetErrorWrapper("ClickHouseLogWriter.WriteLogs")
+
+	db, err := w.clickHouse.GetConnection()
+	if err != nil {
+		return ew(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return ew(err)
+	}
+
+	if !w.isTableCreated {
+		_, err = sqlDB.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	timestamp DateTime64(6),
+	level LowCardinality(String),
+	logger String,
+	message String,
+	caller String,
+	fields Map(String, String)
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (timestamp, level, logger)`, w.table))
+		if err != nil {
+			return ew(err)
+		}
+
+		w.isTableCreated = true
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return ew(err)
+	}
+
+	//nolint:gosec // table name is checked by clickHouseLogTableRegexp
+	statement, err := tx.Prepare(fmt.Sprintf(
+		"INSERT INTO %s (timestamp, level, logger, message, caller, fields)", w.table,
+	))
+	if err != nil {
+		_ = tx.Rollback()
+		return ew(err)
+	}
+	defer statement.Close()
+
+	for _, entry := range entries {
+		_, err = statement.Exec(entry.Timestamp, entry.Level, entry.Logger, entry.Message, entry.Caller, entry.Fields)
+		if err != nil {
+			_ = tx.Rollback()
+			return ew(err)
+		}
+	}
+
+	return ew(tx.Commit())
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// errorDetailsKeySuffix is added to the key of an error field for its details, see [tools.ErrorDetails],
+// like zap adds "Verbose" and "Causes" suffixes.
+const errorDetailsKeySuffix = "Details"
+
+// NewErrorDetailsCore returns core which writes details of errors wrapped by [tools.ErrorWrapperCreator]
+// as an object next to them, e.g. "errorDetails" for [zap.Error], see [tools.ErrorDetails].
+//
+// [NewLogger] uses it for every sink before redaction, so details are redacted too.
+// A logger created without it, e.g. by [zap.NewProduction], writes only the message of such errors.
+func NewErrorDetailsCore(core zapcore.Core) zapcore.Core {
+	return &errorDetailsCore{core: core}
+}
+
+// AddErrorDetails returns fields with details added after every error field which has them.
+func AddErrorDetails(fields []zapcore.Field) []zapcore.Field {
+	var withDetails []zapcore.Field
+
+	for i, field := range fields {
+		details, ok := getErrorFieldDetails(field)
+		if !ok {
+			if withDetails != nil {
+				withDetails = append(withDetails, field)
+			}
+
+			continue
+		}
+
+		// fields are copied only if there are details
+		if withDetails == nil {
+			withDetails = append(make([]zapcore.Field, 0, len(fields)+1), fields[:i]...)
+		}
+
+		withDetails = append(withDetails, field, zap.Object(field.Key+errorDetailsKeySuffix, details))
+	}
+
+	if withDetails == nil {
+		return fields
+	}
+
+	return withDetails
+}
+
+//nolint:ireturn
+func getErrorFieldDetails(field zapcore.Field) (zapcore.ObjectMarshaler, bool) {
+	if field.Type != zapcore.ErrorType {
+		return nil, false
+	}
+
+	err, _ := field.Interface.(error)
+	if err == nil {
+		return nil, false
+	}
+
+	return tools.ErrorDetails(err)
+}
+
+// errorDetailsCore adds details of errors to fields and writes them to core, filtering by level is left to core.
+type errorDetailsCore struct {
+	core zapcore.Core
+}
+
+// Enabled implements [zapcore.LevelEnabler].
+func (c *errorDetailsCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+// With implements [zapcore.Core].
+func (c *errorDetailsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorDetailsCore{core: c.core.With(AddErrorDetails(fields))}
+}
+
+// Check implements [zapcore.Core].
+func (c *errorDetailsCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *errorDetailsCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.core.Write(entry, AddErrorDetails(fields))
+}
+
+// Sync implements [zapcore.Core].
+func (c *errorDetailsCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// Names of log encoders.
+const (
+	LoggerEncoderJSON    = "json"
+	LoggerEncoderConsole = "console"
+	LoggerEncoderLogfmt  = "logfmt"
+)
+
+// LoggerEncoderNames contains all encoders supported by [LoggerFormatConfig].
+var LoggerEncoderNames = []string{LoggerEncoderJSON, LoggerEncoderConsole, LoggerEncoderLogfmt}
+
+// LoggerLevelNone disables caller or stacktrace in [LoggerFormatConfig].
+const LoggerLevelNone = "none"
+
+// Names of console outputs.
+const (
+	LoggerOutputStdout = "stdout"
+	LoggerOutputStderr = "stderr"
+)
+
+// LoggerOutputNames contains all outputs supported by [LoggerConsoleConfig].
+var LoggerOutputNames = []string{LoggerOutputStdout, LoggerOutputStderr}
+
+// loggerTimeEncoders contains named time formats, other formats are treated as Go layouts.
+var loggerTimeEncoders = map[string]zapcore.TimeEncoder{
+	"iso8601":      zapcore.ISO8601TimeEncoder,
+	"rfc3339":      zapcore.RFC3339TimeEncoder,
+	"rfc3339nano":  zapcore.RFC3339NanoTimeEncoder,
+	"epoch":        zapcore.EpochTimeEncoder,
+	"epoch_millis": zapcore.EpochMillisTimeEncoder,
+	"epoch_nanos":  zapcore.EpochNanosTimeEncoder,
+}
+
+// LoggerTimeFormatNames contains named time formats supported by [LoggerFormatConfig].
+var LoggerTimeFormatNames = tools.SortMapKeys(loggerTimeEncoders)
+
+var (
+	// ErrUnknownLogEncoder is returned when an encoder is not one of [LoggerEncoderNames].
+	ErrUnknownLogEncoder = errors.New("unknown log encoder")
+	// ErrUnknownLogTimeFormat is returned when a time format is neither one of [LoggerTimeFormatNames] nor a Go layout.
+	ErrUnknownLogTimeFormat = errors.New("unknown log time format")
+	// ErrUnknownLogOutput is returned when a console output is not one of [LoggerOutputNames].
+	ErrUnknownLogOutput = errors.New("unknown log output")
+)
+
+// LoggerFormatConfig describes how entries of a sink are encoded.
+// Empty values are replaced by defaults of the sink.
+type LoggerFormatConfig struct {
+	// Encoder is one of [LoggerEncoderNames].
+	Encoder string
+	// Color enables colored levels, it is ignored by json encoder.
+	Color bool
+	// TimeFormat is one of [LoggerTimeFormatNames] or a Go layout, e.g. "2006-01-02 15:04:05". Default is iso8601.
+	TimeFormat string
+	// CallerLevel is the minimal level of entries with caller or [LoggerLevelNone]. Default is debug.
+	CallerLevel string
+	// StacktraceLevel is the minimal level of entries with stacktrace or [LoggerLevelNone]. Default is none.
+	StacktraceLevel string
+}
+
+// Validate checks configuration values.
+func (c *LoggerFormatConfig) Validate(v *tools.ConfigValidator) {
+	_, err := c.getEncoderName("")
+	v.Check(err == nil, "encoder", "%s", err)
+
+	_, err = c.getTimeEncoder()
+	v.Check(err == nil, "time_format", "%s", err)
+
+	_, err = parseOptionalZapLevel(c.CallerLevel, zapcore.DebugLevel)
+	v.Check(err == nil, "caller_level", "%s", err)
+
+	_, err = parseOptionalZapLevel(c.StacktraceLevel, zapcore.InvalidLevel)
+	v.Check(err == nil, "stacktrace_level", "%s", err)
+}
+
+func (c *LoggerFormatConfig) getEncoderName(defaultEncoder string) (string, error) {
+	if c == nil || c.Encoder == "" {
+		return defaultEncoder, nil
+	}
+
+	for _, name := range LoggerEncoderNames {
+		if c.Encoder == name {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q, expected one of %s",
+		ErrUnknownLogEncoder, c.Encoder, strings.Join(LoggerEncoderNames, ", "),
+	)
+}
+
+func (c *LoggerFormatConfig) getTimeEncoder() (zapcore.TimeEncoder, error) {
+	if c == nil || c.TimeFormat == "" {
+		return zapcore.ISO8601TimeEncoder, nil
+	}
+
+	if encoder, ok := loggerTimeEncoders[c.TimeFormat]; ok {
+		return encoder, nil
+	}
+
+	// every Go layout contains the reference year
+	if strings.Contains(c.TimeFormat, "2006") {
+		return zapcore.TimeEncoderOfLayout(c.TimeFormat), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q, expected Go layout or one of %s",
+		ErrUnknownLogTimeFormat, c.TimeFormat, strings.Join(LoggerTimeFormatNames, ", "),
+	)
+}
+
+// getEntryLevels returns minimal levels of entries with caller and stacktrace,
+// [zapcore.InvalidLevel] means never.
+func (c *LoggerFormatConfig) getEntryLevels() (zapcore.Level, zapcore.Level, error) {
+	if c == nil {
+		return zapcore.DebugLevel, zapcore.InvalidLevel, nil
+	}
+
+	callerLevel, err := parseOptionalZapLevel(c.CallerLevel, zapcore.DebugLevel)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stacktraceLevel, err := parseOptionalZapLevel(c.StacktraceLevel, zapcore.InvalidLevel)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return callerLevel, stacktraceLevel, nil
+}
+
+// parseOptionalZapLevel parses level, which may be empty or [LoggerLevelNone].
+func parseOptionalZapLevel(level string, defaultLevel zapcore.Level) (zapcore.Level, error) {
+	switch level {
+	case "":
+		return defaultLevel, nil
+	case LoggerLevelNone:
+		return zapcore.InvalidLevel, nil
+	default:
+		return ParseZapLevel(level)
+	}
+}
+
+// NewLoggerEncoder creates an encoder from config, defaultEncoder is used if the encoder is not set.
+func NewLoggerEncoder(config *LoggerFormatConfig, defaultEncoder string) (zapcore.Encoder, error) {
+	ew := tools.GetErrorWrapper("NewLoggerEncoder")
+
+	name, err := config.getEncoderName(defaultEncoder)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	encoderConfig := NewProductionEncoderConfig()
+
+	encoderConfig.EncodeTime, err = config.getTimeEncoder()
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	if config != nil && config.Color {
+		encoderConfig.EncodeLevel = zapcore.LowercaseColorLevelEncoder
+	}
+
+	switch name {
+	case LoggerEncoderConsole:
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case LoggerEncoderLogfmt:
+		return NewLogfmtEncoder(encoderConfig), nil
+	default:
+		encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	}
+}
+
+// newFormattedZapCore creates a core writing entries encoded according to config.
+func newFormattedZapCore(
+	config *LoggerFormatConfig,
+	defaultEncoder string,
+	writer io.Writer,
+	level zapcore.LevelEnabler,
+) (zapcore.Core, error) {
+	ew := tools.GetErrorWrapper("newFormattedZapCore")
+
+	encoder, err := NewLoggerEncoder(config, defaultEncoder)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	core, err := newEntryFormatCore(config, zapcore.NewCore(encoder, zapcore.AddSync(writer), level))
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return core, nil
+}
+
+// newEntryFormatCore wraps core to write caller and stacktrace according to config.
+func newEntryFormatCore(config *LoggerFormatConfig, core zapcore.Core) (zapcore.Core, error) {
+	callerLevel, stacktraceLevel, err := config.getEntryLevels()
+	if err != nil {
+		return nil, tools.WrapMethodError(err, "newEntryFormatCore")
+	}
+
+	return &entryFormatCore{
+		Core:            core,
+		callerLevel:     callerLevel,
+		stacktraceLevel: stacktraceLevel,
+	}, nil
+}
+
+func getConsoleWriter(output string) (io.Writer, error) {
+	switch output {
+	case "", LoggerOutputStdout:
+		return os.Stdout, nil
+	case LoggerOutputStderr:
+		return os.Stderr, nil
+	default:
+		return nil, fmt.Errorf("%w: %q, expected one of %s",
+			ErrUnknownLogOutput, output, strings.Join(LoggerOutputNames, ", "),
+		)
+	}
+}
+
+// entryFormatCore removes caller and stacktrace from entries below configured levels.
+// Logger must add them to entries, see [NewLogger].
+type entryFormatCore struct {
+	zapcore.Core
+	callerLevel     zapcore.Level
+	stacktraceLevel zapcore.Level
+}
+
+// With implements [zapcore.Core].
+func (c *entryFormatCore) With(fields []zapcore.Field) zapcore.Core {
+	return &entryFormatCore{
+		Core:            c.Core.With(fields),
+		callerLevel:     c.callerLevel,
+		stacktraceLevel: c.stacktraceLevel,
+	}
+}
+
+// Check implements [zapcore.Core].
+func (c *entryFormatCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *entryFormatCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level < c.callerLevel {
+		entry.Caller = zapcore.EntryCaller{}
+	}
+
+	if entry.Level < c.stacktraceLevel {
+		entry.Stack = ""
+	}
+
+	return c.Core.Write(entry, fields)
+}
@@ -0,0 +1,356 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtBufferPool = buffer.NewPool()
+
+// logfmtEncoder encodes entries as key=value pairs, e.g.
+//
+//	ts=2024-01-02T15:04:05.000Z level=info logger=S3Manager msg="Bucket created" bucket=files
+//
+// Keys of nested namespaces are joined by dots, arrays and objects are encoded as JSON.
+type logfmtEncoder struct {
+	config     *zapcore.EncoderConfig
+	buf        *buffer.Buffer
+	namespaces []string
+}
+
+// NewLogfmtEncoder creates a [zapcore.Encoder] writing entries in logfmt format.
+func NewLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{config: &config, buf: logfmtBufferPool.Get()}
+}
+
+func (e *logfmtEncoder) addKey(key string) {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+
+	for _, namespace := range e.namespaces {
+		e.buf.AppendString(namespace)
+		e.buf.AppendByte('.')
+	}
+
+	e.buf.AppendString(key)
+	e.buf.AppendByte('=')
+}
+
+// addValue adds a key with value quoted if needed.
+func (e *logfmtEncoder) addValue(key string, value string) {
+	e.addKey(key)
+
+	if logfmtNeedsQuoting(value) {
+		e.buf.AppendString(strconv.Quote(value))
+		return
+	}
+
+	e.buf.AppendString(value)
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addJSON adds value encoded as JSON.
+func (e *logfmtEncoder) addJSON(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	e.addValue(key, string(encoded))
+
+	return nil
+}
+
+// AddArray implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	if err := encoder.AddArray(key, marshaler); err != nil {
+		return err
+	}
+
+	return e.addJSON(key, encoder.Fields[key])
+}
+
+// AddObject implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	if err := marshaler.MarshalLogObject(encoder); err != nil {
+		return err
+	}
+
+	return e.addJSON(key, encoder.Fields)
+}
+
+// AddBinary implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddBinary(key string, value []byte) {
+	e.addValue(key, base64.StdEncoding.EncodeToString(value))
+}
+
+// AddByteString implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddByteString(key string, value []byte) {
+	e.addValue(key, string(value))
+}
+
+// AddBool implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddBool(key string, value bool) {
+	e.addValue(key, strconv.FormatBool(value))
+}
+
+// AddComplex128 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddComplex128(key string, value complex128) {
+	e.addValue(key, strconv.FormatComplex(value, 'f', -1, 128))
+}
+
+// AddComplex64 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddComplex64(key string, value complex64) {
+	e.addValue(key, strconv.FormatComplex(complex128(value), 'f', -1, 64))
+}
+
+// AddDuration implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	e.addEncoded(key, func(encoder zapcore.PrimitiveArrayEncoder) {
+		if e.config.EncodeDuration == nil {
+			encoder.AppendString(value.String())
+			return
+		}
+
+		e.config.EncodeDuration(value, encoder)
+	})
+}
+
+// AddFloat64 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddFloat64(key string, value float64) {
+	e.addValue(key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// AddFloat32 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddFloat32(key string, value float32) {
+	e.addValue(key, strconv.FormatFloat(float64(value), 'f', -1, 32))
+}
+
+// AddInt implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddInt(key string, value int) { e.AddInt64(key, int64(value)) }
+
+// AddInt64 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddInt64(key string, value int64) {
+	e.addValue(key, strconv.FormatInt(value, 10))
+}
+
+// AddInt32 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+
+// AddInt16 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+
+// AddInt8 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddInt8(key string, value int8) { e.AddInt64(key, int64(value)) }
+
+// AddString implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddString(key, value string) {
+	e.addValue(key, value)
+}
+
+// AddTime implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddTime(key string, value time.Time) {
+	e.addEncoded(key, func(encoder zapcore.PrimitiveArrayEncoder) {
+		if e.config.EncodeTime == nil {
+			encoder.AppendString(value.Format(time.RFC3339Nano))
+			return
+		}
+
+		e.config.EncodeTime(value, encoder)
+	})
+}
+
+// AddUint implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUint(key string, value uint) { e.AddUint64(key, uint64(value)) }
+
+// AddUint64 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUint64(key string, value uint64) {
+	e.addValue(key, strconv.FormatUint(value, 10))
+}
+
+// AddUint32 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUint32(key string, value uint32) { e.AddUint64(key, uint64(value)) }
+
+// AddUint16 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUint16(key string, value uint16) { e.AddUint64(key, uint64(value)) }
+
+// AddUint8 implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUint8(key string, value uint8) { e.AddUint64(key, uint64(value)) }
+
+// AddUintptr implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+// AddReflected implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	return e.addJSON(key, value)
+}
+
+// OpenNamespace implements [zapcore.ObjectEncoder].
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.namespaces = append(e.namespaces, key)
+}
+
+// addEncoded adds a value produced by one of encoders of [zapcore.EncoderConfig].
+func (e *logfmtEncoder) addEncoded(key string, encode func(encoder zapcore.PrimitiveArrayEncoder)) {
+	value := &logfmtValueEncoder{}
+	encode(value)
+	e.addValue(key, strings.Join(value.values, ","))
+}
+
+// Clone implements [zapcore.Encoder].
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return e.clone()
+}
+
+func (e *logfmtEncoder) clone() *logfmtEncoder {
+	clone := &logfmtEncoder{
+		config:     e.config,
+		buf:        logfmtBufferPool.Get(),
+		namespaces: append([]string{}, e.namespaces...),
+	}
+	clone.buf.AppendString(e.buf.String())
+
+	return clone
+}
+
+// EncodeEntry implements [zapcore.Encoder].
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := &logfmtEncoder{config: e.config, buf: logfmtBufferPool.Get()}
+
+	if e.config.TimeKey != zapcore.OmitKey && e.config.TimeKey != "" {
+		line.AddTime(e.config.TimeKey, entry.Time)
+	}
+
+	if e.config.LevelKey != zapcore.OmitKey && e.config.LevelKey != "" && e.config.EncodeLevel != nil {
+		// level is not quoted, so colors are kept
+		value := &logfmtValueEncoder{}
+		e.config.EncodeLevel(entry.Level, value)
+		line.addKey(e.config.LevelKey)
+		line.buf.AppendString(strings.Join(value.values, ","))
+	}
+
+	if entry.LoggerName != "" && e.config.NameKey != zapcore.OmitKey && e.config.NameKey != "" {
+		line.AddString(e.config.NameKey, entry.LoggerName)
+	}
+
+	if entry.Caller.Defined && e.config.CallerKey != zapcore.OmitKey && e.config.CallerKey != "" {
+		line.addEncoded(e.config.CallerKey, func(encoder zapcore.PrimitiveArrayEncoder) {
+			if e.config.EncodeCaller == nil {
+				encoder.AppendString(entry.Caller.TrimmedPath())
+				return
+			}
+
+			e.config.EncodeCaller(entry.Caller, encoder)
+		})
+	}
+
+	if e.config.MessageKey != zapcore.OmitKey && e.config.MessageKey != "" {
+		line.AddString(e.config.MessageKey, entry.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		line.buf.AppendByte(' ')
+		line.buf.AppendString(e.buf.String())
+	}
+
+	line.namespaces = append(line.namespaces, e.namespaces...)
+	for _, field := range fields {
+		field.AddTo(line)
+	}
+
+	line.namespaces = nil
+
+	if entry.Stack != "" && e.config.StacktraceKey != zapcore.OmitKey && e.config.StacktraceKey != "" {
+		line.AddString(e.config.StacktraceKey, entry.Stack)
+	}
+
+	if e.config.LineEnding == "" {
+		line.buf.AppendString(zapcore.DefaultLineEnding)
+	} else {
+		line.buf.AppendString(e.config.LineEnding)
+	}
+
+	return line.buf, nil
+}
+
+// logfmtValueEncoder collects values produced by encoders of [zapcore.EncoderConfig].
+type logfmtValueEncoder struct {
+	values []string
+}
+
+func (v *logfmtValueEncoder) AppendBool(value bool) {
+	v.values = append(v.values, strconv.FormatBool(value))
+}
+
+func (v *logfmtValueEncoder) AppendByteString(value []byte) {
+	v.values = append(v.values, string(value))
+}
+
+func (v *logfmtValueEncoder) AppendComplex128(value complex128) {
+	v.values = append(v.values, strconv.FormatComplex(value, 'f', -1, 128))
+}
+
+func (v *logfmtValueEncoder) AppendComplex64(value complex64) {
+	v.values = append(v.values, strconv.FormatComplex(complex128(value), 'f', -1, 64))
+}
+
+func (v *logfmtValueEncoder) AppendFloat64(value float64) {
+	v.values = append(v.values, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (v *logfmtValueEncoder) AppendFloat32(value float32) {
+	v.values = append(v.values, strconv.FormatFloat(float64(value), 'f', -1, 32))
+}
+
+func (v *logfmtValueEncoder) AppendInt(value int) { v.AppendInt64(int64(value)) }
+
+func (v *logfmtValueEncoder) AppendInt64(value int64) {
+	v.values = append(v.values, strconv.FormatInt(value, 10))
+}
+
+func (v *logfmtValueEncoder) AppendInt32(value int32) { v.AppendInt64(int64(value)) }
+
+func (v *logfmtValueEncoder) AppendInt16(value int16) { v.AppendInt64(int64(value)) }
+
+func (v *logfmtValueEncoder) AppendInt8(value int8) { v.AppendInt64(int64(value)) }
+
+func (v *logfmtValueEncoder) AppendString(value string) {
+	v.values = append(v.values, value)
+}
+
+func (v *logfmtValueEncoder) AppendUint(value uint) { v.AppendUint64(uint64(value)) }
+
+func (v *logfmtValueEncoder) AppendUint64(value uint64) {
+	v.values = append(v.values, strconv.FormatUint(value, 10))
+}
+
+func (v *logfmtValueEncoder) AppendUint32(value uint32) { v.AppendUint64(uint64(value)) }
+
+func (v *logfmtValueEncoder) AppendUint16(value uint16) { v.AppendUint64(uint64(value)) }
+
+func (v *logfmtValueEncoder) AppendUint8(value uint8) { v.AppendUint64(uint64(value)) }
+
+func (v *logfmtValueEncoder) AppendUintptr(value uintptr) { v.AppendUint64(uint64(value)) }
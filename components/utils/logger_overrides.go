@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// loggerOverride is a level of loggers with names starting with prefix.
+type loggerOverride struct {
+	prefix string
+	level  zapcore.Level
+}
+
+// loggerOverrides is an immutable set of overrides, replaced as a whole by [LoggerLevels.SetOverrides].
+type loggerOverrides struct {
+	// sorted by length of prefix descending, so the most specific prefix is found first
+	overrides []loggerOverride
+	minLevel  zapcore.Level
+}
+
+// parseLoggerOverrides converts map of logger name prefix to level name.
+func parseLoggerOverrides(raw map[string]string) (*loggerOverrides, error) {
+	result := &loggerOverrides{
+		overrides: []loggerOverride{},
+		minLevel:  zapcore.InvalidLevel,
+	}
+
+	for prefix, levelName := range raw {
+		level, err := ParseZapLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prefix, err)
+		}
+
+		result.overrides = append(result.overrides, loggerOverride{prefix: prefix, level: level})
+
+		if level < result.minLevel {
+			result.minLevel = level
+		}
+	}
+
+	sort.Slice(result.overrides, func(i, j int) bool {
+		if len(result.overrides[i].prefix) != len(result.overrides[j].prefix) {
+			return len(result.overrides[i].prefix) > len(result.overrides[j].prefix)
+		}
+
+		return result.overrides[i].prefix < result.overrides[j].prefix
+	})
+
+	return result, nil
+}
+
+// find returns level of the most specific override matching name.
+// Prefix "ClickHouse" matches "ClickHouse" and "ClickHouse.GetConnection", but not "ClickHouseManager".
+func (o *loggerOverrides) find(name string) (zapcore.Level, bool) {
+	for _, override := range o.overrides {
+		if name == override.prefix || strings.HasPrefix(name, override.prefix+".") {
+			return override.level, true
+		}
+	}
+
+	return zapcore.InvalidLevel, false
+}
+
+// toMap returns overrides in format of config.
+func (o *loggerOverrides) toMap() map[string]string {
+	result := map[string]string{}
+	for _, override := range o.overrides {
+		result[override.prefix] = override.level.String()
+	}
+
+	return result
+}
+
+// levelFilterCore filters entries of core by level of the sink or by level overridden for the logger name.
+// Core itself must accept all levels.
+type levelFilterCore struct {
+	core      zapcore.Core
+	level     zap.AtomicLevel
+	overrides *atomic.Pointer[loggerOverrides]
+}
+
+func newLevelFilterCore(
+	core zapcore.Core,
+	level zap.AtomicLevel,
+	overrides *atomic.Pointer[loggerOverrides],
+) zapcore.Core {
+	return &levelFilterCore{core: core, level: level, overrides: overrides}
+}
+
+// Enabled implements [zapcore.LevelEnabler].
+// Name of the logger is unknown here, so it is enough for the level to be enabled by any override.
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) || level >= c.overrides.Load().minLevel
+}
+
+// With implements [zapcore.Core].
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return newLevelFilterCore(c.core.With(fields), c.level, c.overrides)
+}
+
+// Check implements [zapcore.Core].
+func (c *levelFilterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !isLevelAccepted(entry, c.level, c.overrides) {
+		return checked
+	}
+
+	return c.core.Check(entry, checked)
+}
+
+// Write implements [zapcore.Core].
+func (c *levelFilterCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.core.Write(entry, fields)
+}
+
+// Sync implements [zapcore.Core].
+func (c *levelFilterCore) Sync() error {
+	return c.core.Sync()
+}
+
+// rejectedFilterCore passes to core only entries which are rejected by level of the sink
+// and by level overridden for the logger name, i.e. entries which [levelFilterCore] with the same levels drops.
+// Core itself must accept all levels.
+type rejectedFilterCore struct {
+	core      zapcore.Core
+	level     zap.AtomicLevel
+	overrides *atomic.Pointer[loggerOverrides]
+}
+
+func newRejectedFilterCore(
+	core zapcore.Core,
+	level zap.AtomicLevel,
+	overrides *atomic.Pointer[loggerOverrides],
+) zapcore.Core {
+	return &rejectedFilterCore{core: core, level: level, overrides: overrides}
+}
+
+// Enabled implements [zapcore.LevelEnabler].
+// Name of the logger is unknown here, so any level may be rejected by an override.
+func (c *rejectedFilterCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+// With implements [zapcore.Core].
+func (c *rejectedFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return newRejectedFilterCore(c.core.With(fields), c.level, c.overrides)
+}
+
+// Check implements [zapcore.Core].
+func (c *rejectedFilterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if isLevelAccepted(entry, c.level, c.overrides) {
+		return checked
+	}
+
+	return c.core.Check(entry, checked)
+}
+
+// Write implements [zapcore.Core].
+func (c *rejectedFilterCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.core.Write(entry, fields)
+}
+
+// Sync implements [zapcore.Core].
+func (c *rejectedFilterCore) Sync() error {
+	return c.core.Sync()
+}
+
+// isLevelAccepted checks the entry by level overridden for its logger name or by level of the sink.
+func isLevelAccepted(entry zapcore.Entry, level zap.AtomicLevel, overrides *atomic.Pointer[loggerOverrides]) bool {
+	overriddenLevel, ok := overrides.Load().find(entry.LoggerName)
+	if ok {
+		return entry.Level >= overriddenLevel
+	}
+
+	return level.Enabled(entry.Level)
+}
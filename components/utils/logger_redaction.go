@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// DefaultRedactedFieldNames are always redacted by [LogRedactor].
+var DefaultRedactedFieldNames = []string{"password", "passwd", "secret", "token", "dsn", "authorization", "api_key"}
+
+// defaultRedactedPatterns are always masked inside strings by [LogRedactor],
+// passwords in connection strings are masked by [tools.MaskConnectionStringPassword].
+var defaultRedactedPatterns = []*regexp.Regexp{
+	// Telegram bot token, e.g. in https://api.telegram.org/bot123456:ABC.../getMe
+	regexp.MustCompile(`\d{6,12}:[A-Za-z0-9_-]{30,}`),
+	// Authorization header value
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`),
+}
+
+type LoggerRedactionConfig struct {
+	// FieldNames are added to [DefaultRedactedFieldNames].
+	FieldNames []string
+	// Patterns are regular expressions, matches are replaced by [tools.SecretMask].
+	Patterns []string
+}
+
+// Validate checks configuration values.
+func (c *LoggerRedactionConfig) Validate(v *tools.ConfigValidator) {
+	for i, pattern := range c.Patterns {
+		_, err := regexp.Compile(pattern)
+		v.Check(err == nil, fmt.Sprintf("patterns[%d]", i), "%s", err)
+	}
+}
+
+// LogRedactor masks sensitive values of log entries.
+//
+// A field is sensitive if its name contains any of redacted names (case-insensitive),
+// e.g. "password" matches "db_password". Values of such fields are replaced by [tools.SecretMask],
+// including nested keys of objects. Credentials inside all other strings and the message are masked by patterns.
+type LogRedactor struct {
+	fieldNames []string
+	patterns   []*regexp.Regexp
+}
+
+// NewLogRedactor creates a [LogRedactor] with default and configured names and patterns.
+// config may be nil.
+func NewLogRedactor(config *LoggerRedactionConfig) (*LogRedactor, error) {
+	r := &LogRedactor{
+		fieldNames: slices.Clone(DefaultRedactedFieldNames),
+		patterns:   slices.Clone(defaultRedactedPatterns),
+	}
+
+	if config == nil {
+		return r, nil
+	}
+
+	for _, name := range config.FieldNames {
+		r.fieldNames = append(r.fieldNames, strings.ToLower(name))
+	}
+
+	for _, pattern := range config.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, tools.WrapMethodError(err, "NewLogRedactor")
+		}
+
+		r.patterns = append(r.patterns, compiled)
+	}
+
+	return r, nil
+}
+
+// IsSensitiveField reports whether values of the field must be hidden.
+func (r *LogRedactor) IsSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, fieldName := range r.fieldNames {
+		if strings.Contains(name, fieldName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactString masks credentials inside text.
+func (r *LogRedactor) RedactString(text string) string {
+	text = tools.MaskConnectionStringPassword(text)
+
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllString(text, tools.SecretMask)
+	}
+
+	return text
+}
+
+// RedactFields returns copies of fields with sensitive values masked.
+func (r *LogRedactor) RedactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, 0, len(fields))
+
+	for _, field := range fields {
+		redacted = append(redacted, r.redactField(field)...)
+	}
+
+	return redacted
+}
+
+func (r *LogRedactor) redactField(field zapcore.Field) []zapcore.Field {
+	if field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+		return []zapcore.Field{field}
+	}
+
+	if r.IsSensitiveField(field.Key) {
+		return []zapcore.Field{zap.String(field.Key, tools.SecretMask)}
+	}
+
+	//nolint:exhaustive
+	switch field.Type {
+	case zapcore.StringType:
+		return []zapcore.Field{zap.String(field.Key, r.RedactString(field.String))}
+	case zapcore.ByteStringType:
+		bytes, _ := field.Interface.([]byte)
+		return []zapcore.Field{zap.String(field.Key, r.RedactString(string(bytes)))}
+	case zapcore.ErrorType:
+		err, _ := field.Interface.(error)
+		if err == nil {
+			return []zapcore.Field{field}
+		}
+
+		return []zapcore.Field{zap.String(field.Key, r.RedactString(err.Error()))}
+	case zapcore.StringerType:
+		stringer, _ := field.Interface.(fmt.Stringer)
+		if stringer == nil {
+			return []zapcore.Field{field}
+		}
+
+		return []zapcore.Field{zap.String(field.Key, r.RedactString(stringer.String()))}
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.ReflectType:
+		// encode to plain values, so nested keys and strings can be checked
+		encoder := zapcore.NewMapObjectEncoder()
+		field.AddTo(encoder)
+
+		keys := make([]string, 0, len(encoder.Fields))
+		for key := range encoder.Fields {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		redacted := make([]zapcore.Field, 0, len(keys))
+		for _, key := range keys {
+			if r.IsSensitiveField(key) {
+				redacted = append(redacted, zap.String(key, tools.SecretMask))
+				continue
+			}
+
+			redacted = append(redacted, zap.Any(key, r.redactValue(encoder.Fields[key])))
+		}
+
+		return redacted
+	default:
+		return []zapcore.Field{field}
+	}
+}
+
+// redactValue masks values produced by [zapcore.MapObjectEncoder].
+func (r *LogRedactor) redactValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case string:
+		return r.RedactString(typedValue)
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(typedValue))
+		for key, nested := range typedValue {
+			if r.IsSensitiveField(key) {
+				redacted[key] = tools.SecretMask
+				continue
+			}
+
+			redacted[key] = r.redactValue(nested)
+		}
+
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, 0, len(typedValue))
+		for _, nested := range typedValue {
+			redacted = append(redacted, r.redactValue(nested))
+		}
+
+		return redacted
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128:
+		return value
+	default:
+		// reflected values (structs, maps, slices) are checked in the form they would be logged
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return r.RedactString(fmt.Sprintf("%+v", value))
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return r.RedactString(string(encoded))
+		}
+
+		return r.redactValue(decoded)
+	}
+}
+
+// WrapCore returns core which redacts the message and fields of entries before writing them to core.
+func (r *LogRedactor) WrapCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{core: core, redactor: r}
+}
+
+// redactingCore redacts entries and writes them to core, filtering by level is left to core.
+type redactingCore struct {
+	core     zapcore.Core
+	redactor *LogRedactor
+}
+
+// Enabled implements [zapcore.LevelEnabler].
+func (c *redactingCore) Enabled(level zapcore.Level) bool {
+	return c.core.Enabled(level)
+}
+
+// With implements [zapcore.Core].
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{core: c.core.With(c.redactor.RedactFields(fields)), redactor: c.redactor}
+}
+
+// Check implements [zapcore.Core].
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.RedactString(entry.Message)
+
+	return c.core.Write(entry, c.redactor.RedactFields(fields))
+}
+
+// Sync implements [zapcore.Core].
+func (c *redactingCore) Sync() error {
+	return c.core.Sync()
+}
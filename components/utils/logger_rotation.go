@@ -0,0 +1,609 @@
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+// Rotation schedules of log files.
+const (
+	LoggerRotationNone   = "none"
+	LoggerRotationDaily  = "daily"
+	LoggerRotationHourly = "hourly"
+)
+
+// LoggerRotationScheduleNames contains all schedules supported by [LoggerRotationConfig].
+var LoggerRotationScheduleNames = []string{LoggerRotationNone, LoggerRotationDaily, LoggerRotationHourly}
+
+// defaultLoggerRotationTimeLayout is used in names of rotated files if a pattern is not set.
+const defaultLoggerRotationTimeLayout = "2006-01-02T15-04-05.000"
+
+const megabyte = 1024 * 1024
+
+// logRotationRetryInterval is a delay of the next rotation by size or schedule after a failed one,
+// so each write does not retry it.
+const logRotationRetryInterval = time.Minute
+
+var (
+	// ErrUnknownLogRotationSchedule is returned when a schedule is not one of [LoggerRotationScheduleNames].
+	ErrUnknownLogRotationSchedule = errors.New("unknown log rotation schedule")
+	// ErrInvalidLogRotationPattern is returned when a pattern of rotated file names is not a Go time layout.
+	ErrInvalidLogRotationPattern = errors.New("invalid log rotation pattern")
+)
+
+// rotatedLogIndexRegexp matches an index added to names of files rotated in the same period, e.g. "-2" in "app-2024-01-02-2.log".
+var rotatedLogIndexRegexp = regexp.MustCompile(`-\d+$`)
+
+type LoggerRotationConfig struct {
+	// MaxSize is a size in megabytes after which the file is rotated, 0 - no limit.
+	MaxSize int
+	// MaxAge is a number of days to keep rotated files, 0 - no limit.
+	MaxAge int
+	// MaxBackups is a number of rotated files to keep, 0 - no limit.
+	MaxBackups int
+	// MaxTotalSize is a disk budget of rotated files in megabytes, the oldest ones are removed first, 0 - no limit.
+	MaxTotalSize int
+	// Localtime is used for schedule and names of rotated files instead of UTC.
+	Localtime bool
+	Compress  bool
+	// Schedule is one of [LoggerRotationScheduleNames], empty means none - rotation by size and signal only.
+	Schedule string
+	// Pattern is a Go time layout of names of rotated files in the directory of the file, e.g. "app-2006-01-02.log".
+	// An index is added before the extension if the name is taken, e.g. "app-2024-01-02-1.log".
+	// Default is the name of the file with time, e.g. "app-2024-01-02T15-04-05.000.log".
+	Pattern string
+}
+
+// Validate checks configuration values.
+func (c *LoggerRotationConfig) Validate(v *tools.ConfigValidator) {
+	v.NotNegative("max_size", int64(c.MaxSize))
+	v.NotNegative("max_age", int64(c.MaxAge))
+	v.NotNegative("max_backups", int64(c.MaxBackups))
+	v.NotNegative("max_total_size", int64(c.MaxTotalSize))
+
+	_, err := getLogRotationPeriod(c.Schedule)
+	v.Check(err == nil, "schedule", "%s", err)
+
+	err = validateLogRotationPattern(c.Pattern)
+	v.Check(err == nil, "pattern", "%s", err)
+}
+
+// getLogRotationPeriod returns period of the schedule, 0 if the schedule is empty.
+func getLogRotationPeriod(schedule string) (time.Duration, error) {
+	switch schedule {
+	case "", LoggerRotationNone:
+		return 0, nil
+	case LoggerRotationDaily:
+		return 24 * time.Hour, nil
+	case LoggerRotationHourly:
+		return time.Hour, nil
+	default:
+		return 0, fmt.Errorf("%w: %q, expected one of %s",
+			ErrUnknownLogRotationSchedule, schedule, strings.Join(LoggerRotationScheduleNames, ", "),
+		)
+	}
+}
+
+func validateLogRotationPattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	if strings.ContainsRune(pattern, filepath.Separator) || strings.ContainsRune(pattern, '/') {
+		return fmt.Errorf("%w: %q must be a file name without directory", ErrInvalidLogRotationPattern, pattern)
+	}
+
+	// a layout without time elements produces the same name for all files
+	if pattern == time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(pattern) {
+		return fmt.Errorf("%w: %q must contain time elements, e.g. 2006-01-02", ErrInvalidLogRotationPattern, pattern)
+	}
+
+	return nil
+}
+
+// LogFile is a log file which is rotated by size, schedule and [LogFile.Rotate].
+// Rotated files are compressed and removed by age, count and total size in a background goroutine.
+//
+// Without rotation config the file is only reopened by [LogFile.Rotate],
+// so it can be rotated by external tools like logrotate.
+// Safe for concurrent use.
+type LogFile struct {
+	path   string
+	config *LoggerRotationConfig
+	period time.Duration
+	now    func() time.Time
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// rotation by size or schedule is not retried before retryAt after a failure
+	retryAt time.Time
+	// the file is not reopened after Close
+	closed bool
+
+	mill        chan struct{}
+	millDone    chan struct{}
+	millStopped bool
+}
+
+// NewLogFile opens the file for appending, its directory is created if needed.
+// config may be nil, then the file is never rotated.
+// now is used for rotation schedule and names of rotated files, nil means [time.Now].
+// Use [LogFile.Close] to stop background cleanup.
+func NewLogFile(path string, config *LoggerRotationConfig, now func() time.Time) (*LogFile, error) {
+	ew := tools.GetErrorWrapper("NewLogFile")
+
+	if now == nil {
+		now = time.Now
+	}
+
+	f := &LogFile{path: path, config: config, now: now}
+
+	if config != nil {
+		period, err := getLogRotationPeriod(config.Schedule)
+		if err != nil {
+			return nil, ew(err)
+		}
+
+		if err := validateLogRotationPattern(config.Pattern); err != nil {
+			return nil, ew(err)
+		}
+
+		f.period = period
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, ew(err)
+	}
+
+	if config != nil {
+		f.mill = make(chan struct{}, 1)
+		f.millDone = make(chan struct{})
+
+		go f.runMill()
+	}
+
+	f.mutex.Lock()
+	err := f.open()
+	f.mutex.Unlock()
+
+	if err != nil {
+		f.stopMill()
+		return nil, ew(err)
+	}
+
+	return f, nil
+}
+
+// Write implements [io.Writer], the file is rotated before writing if needed.
+// If rotation fails, data is still written to the current file and the error of rotation is returned,
+// the next rotation is tried after logRotationRetryInterval.
+// If the file was moved by rotation but the new one was not opened, opening is retried on each write.
+func (f *LogFile) Write(data []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, tools.WrapMethodError(err, "LogFile.Write")
+		}
+	}
+
+	var rotateErr error
+
+	if now := f.now(); f.config != nil && !now.Before(f.retryAt) {
+		isSizeExceeded := f.config.MaxSize > 0 && f.size > 0 && f.size+int64(len(data)) > int64(f.config.MaxSize)*megabyte
+		if isSizeExceeded || f.isPeriodFinished(now) {
+			rotateErr = tools.WrapMethodError(f.rotate(), "LogFile.Write")
+			if f.file == nil {
+				return 0, rotateErr
+			}
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+
+	if err == nil {
+		err = rotateErr
+	}
+
+	return n, err
+}
+
+// Sync implements [zapcore.WriteSyncer].
+func (f *LogFile) Sync() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Sync()
+}
+
+// Rotate rotates the file if rotation is configured, otherwise reopens it.
+func (f *LogFile) Rotate() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ew := tools.GetErrorWrapper("LogFile.Rotate")
+
+	if f.closed {
+		return ew(os.ErrClosed)
+	}
+
+	if f.file == nil {
+		// the file was moved by the previous rotation, only the new one must be opened
+		return ew(f.open())
+	}
+
+	if f.config != nil {
+		return ew(f.rotate())
+	}
+
+	if err := f.file.Close(); err != nil {
+		return ew(err)
+	}
+
+	return ew(f.open())
+}
+
+// Close closes the file and waits for background cleanup.
+func (f *LogFile) Close() error {
+	f.mutex.Lock()
+
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+
+	f.closed = true
+
+	f.mutex.Unlock()
+
+	f.stopMill()
+
+	return tools.WrapMethodError(err, "LogFile.Close")
+}
+
+// open opens the file, an existing file is rotated first if its period is finished.
+func (f *LogFile) open() error {
+	info, err := os.Stat(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	f.openedAt = f.now()
+
+	if err == nil && f.config != nil && info.Size() > 0 && f.getPeriodStart(info.ModTime()).Before(f.getPeriodStart(f.openedAt)) {
+		if err := f.moveToBackup(info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err = file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *LogFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	f.file = nil
+
+	if f.size > 0 {
+		if err := f.moveToBackup(f.openedAt); err != nil {
+			// the current file is used until the next rotation
+			f.retryAt = f.now().Add(logRotationRetryInterval)
+			return errors.Join(err, f.reopen())
+		}
+	}
+
+	return f.open()
+}
+
+// reopen opens the file for appending without rotation, e.g. after rotation failed.
+func (f *LogFile) reopen() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+
+	return nil
+}
+
+// isPeriodFinished reports whether now is in a later schedule period than the file was opened in.
+func (f *LogFile) isPeriodFinished(now time.Time) bool {
+	return f.period > 0 && f.getPeriodStart(f.openedAt).Before(f.getPeriodStart(now))
+}
+
+func (f *LogFile) getPeriodStart(moment time.Time) time.Time {
+	if f.period == 0 {
+		return time.Time{}
+	}
+
+	moment = f.convertTime(moment)
+
+	if f.period == time.Hour {
+		return time.Date(moment.Year(), moment.Month(), moment.Day(), moment.Hour(), 0, 0, 0, moment.Location())
+	}
+
+	return time.Date(moment.Year(), moment.Month(), moment.Day(), 0, 0, 0, 0, moment.Location())
+}
+
+func (f *LogFile) convertTime(moment time.Time) time.Time {
+	if f.config.Localtime {
+		return moment.Local()
+	}
+
+	return moment.UTC()
+}
+
+// moveToBackup renames the file to a name of rotated file with the time and notifies the mill.
+func (f *LogFile) moveToBackup(moment time.Time) error {
+	name, err := f.getBackupName(moment)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.path, name); err != nil {
+		return err
+	}
+
+	select {
+	case f.mill <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// getBackupName returns a free name for a rotated file, compressed files are taken into account.
+func (f *LogFile) getBackupName(moment time.Time) (string, error) {
+	prefix, layout, ext := f.getBackupNameParts()
+	name := prefix + f.convertTime(moment).Format(layout)
+
+	for i := 0; ; i++ {
+		candidate := name + ext
+		if i > 0 {
+			candidate = name + "-" + strconv.Itoa(i) + ext
+		}
+
+		candidate = filepath.Join(filepath.Dir(f.path), candidate)
+
+		if !isFileExist(candidate) && !isFileExist(candidate+".gz") {
+			return candidate, nil
+		}
+	}
+}
+
+func isFileExist(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// getBackupNameParts splits names of rotated files into a literal prefix, a time layout and an extension.
+// The name of the file is kept out of the layout, since it may contain layout elements like "Mon" or "pm".
+func (f *LogFile) getBackupNameParts() (prefix string, layout string, ext string) {
+	if f.config.Pattern != "" {
+		ext = filepath.Ext(f.config.Pattern)
+		return "", strings.TrimSuffix(f.config.Pattern, ext), ext
+	}
+
+	base := filepath.Base(f.path)
+	ext = filepath.Ext(base)
+
+	return strings.TrimSuffix(base, ext) + "-", defaultLoggerRotationTimeLayout, ext
+}
+
+// isBackupName reports whether the name is produced by [LogFile.getBackupName], possibly compressed.
+func (f *LogFile) isBackupName(name string) bool {
+	prefix, layout, ext := f.getBackupNameParts()
+	name = strings.TrimSuffix(name, ".gz")
+
+	if name == filepath.Base(f.path) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return false
+	}
+
+	timePart := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+	if _, err := time.Parse(layout, timePart); err == nil {
+		return true
+	}
+
+	_, err := time.Parse(layout, rotatedLogIndexRegexp.ReplaceAllString(timePart, ""))
+
+	return err == nil
+}
+
+func (f *LogFile) stopMill() {
+	if f.mill == nil {
+		return
+	}
+
+	f.mutex.Lock()
+	millStopped := f.millStopped
+	f.millStopped = true
+	f.mutex.Unlock()
+
+	if !millStopped {
+		close(f.mill)
+	}
+
+	<-f.millDone
+}
+
+// runMill compresses and removes rotated files after each rotation.
+func (f *LogFile) runMill() {
+	defer close(f.millDone)
+
+	for range f.mill {
+		// errors cannot be logged here, since the file is a part of the logger
+		_ = f.compressBackups()
+		_ = f.removeBackups()
+	}
+}
+
+// logBackup is a rotated file.
+type logBackup struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// getBackups returns rotated files sorted from newest to oldest.
+func (f *LogFile) getBackups() ([]logBackup, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []logBackup{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !f.isBackupName(name) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, logBackup{
+			path:    filepath.Join(filepath.Dir(f.path), name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	return backups, nil
+}
+
+func (f *LogFile) compressBackups() error {
+	if !f.config.Compress {
+		return nil
+	}
+
+	backups, err := f.getBackups()
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+
+	for _, backup := range backups {
+		if !strings.HasSuffix(backup.path, ".gz") {
+			errs = append(errs, compressLogFile(backup.path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeBackups removes rotated files exceeding count, age and total size limits.
+func (f *LogFile) removeBackups() error {
+	backups, err := f.getBackups()
+	if err != nil {
+		return err
+	}
+
+	minModTime := f.now().AddDate(0, 0, -f.config.MaxAge)
+	totalSize := int64(0)
+	errs := []error{}
+
+	for i, backup := range backups {
+		totalSize += backup.size
+
+		isExtra := f.config.MaxBackups > 0 && i >= f.config.MaxBackups
+		isOld := f.config.MaxAge > 0 && backup.modTime.Before(minModTime)
+		isOverBudget := f.config.MaxTotalSize > 0 && totalSize > int64(f.config.MaxTotalSize)*megabyte
+
+		if isExtra || isOld || isOverBudget {
+			errs = append(errs, os.Remove(backup.path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// compressLogFile replaces the file with its gzip archive, the modification time is kept for retention.
+func compressLogFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	destination, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(destination)
+
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := os.Chtimes(path+".gz", info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,567 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+const (
+	// syslogLogSyncTimeout limits waiting for sending in [SyslogLogSink.Sync] and [SyslogLogSink.Close].
+	syslogLogSyncTimeout = 10 * time.Second
+	// syslogWriteTimeout limits writing of a message, so a stuck server does not stop the sink.
+	syslogWriteTimeout = 5 * time.Second
+	// syslogTimestampLayout is TIMESTAMP of RFC5424 with microseconds.
+	syslogTimestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// Networks of syslog servers.
+const (
+	SyslogNetworkUDP      = "udp"
+	SyslogNetworkTCP      = "tcp"
+	SyslogNetworkUnix     = "unix"
+	SyslogNetworkUnixgram = "unixgram"
+)
+
+// SyslogNetworkNames contains all networks supported by [SyslogLogSink].
+var SyslogNetworkNames = []string{SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkUnix, SyslogNetworkUnixgram}
+
+// syslogFacilities contains codes of facilities by names, see RFC5424 section 6.2.1.
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// SyslogFacilityNames contains all facilities supported by [SyslogLogSink].
+var SyslogFacilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var (
+	// ErrUnknownSyslogNetwork is returned when a network is not one of [SyslogNetworkNames].
+	ErrUnknownSyslogNetwork = errors.New("unknown syslog network")
+	// ErrUnknownSyslogFacility is returned when a facility is not one of [SyslogFacilityNames].
+	ErrUnknownSyslogFacility = errors.New("unknown syslog facility")
+	// errSyslogReconnectDelayed is returned while the sink waits before the next connection attempt.
+	errSyslogReconnectDelayed = errors.New("syslog reconnection is delayed")
+)
+
+type LoggerSyslogConfig struct {
+	Level string
+	// Network is one of [SyslogNetworkNames].
+	Network string
+	// Address is host:port for udp and tcp or a socket path for unix and unixgram, e.g. /dev/log.
+	Address string
+	// Facility is one of [SyslogFacilityNames].
+	Facility string
+	// AppName is APP-NAME of messages, default is the name of the executable.
+	AppName string
+	// Hostname is HOSTNAME of messages, default is the name of the host.
+	Hostname string
+	// BufferSize is a number of entries waiting for sending, new entries are dropped if it is exceeded.
+	BufferSize int
+	// ReconnectInterval is a minimal interval between connection attempts after a failure.
+	ReconnectInterval time.Duration
+	// Format may be nil, default encoder is json.
+	Format *LoggerFormatConfig
+}
+
+// Validate checks configuration values.
+func (c *LoggerSyslogConfig) Validate(v *tools.ConfigValidator) {
+	validateZapLevel(v, c.Level)
+
+	_, err := getSyslogFraming(c.Network)
+	v.Check(err == nil, "network", "%s", err)
+
+	v.Required("address", c.Address)
+
+	_, err = getSyslogFacility(c.Facility)
+	v.Check(err == nil, "facility", "%s", err)
+
+	v.Positive("buffer_size", int64(c.BufferSize))
+	v.NotNegative("reconnect_interval", int64(c.ReconnectInterval))
+
+	if c.Format != nil {
+		c.Format.Validate(v.Section("format"))
+	}
+}
+
+// getSyslogFraming reports whether messages must be framed by octet counting (RFC6587) for the network.
+func getSyslogFraming(network string) (bool, error) {
+	switch network {
+	case SyslogNetworkTCP, SyslogNetworkUnix:
+		return true, nil
+	case SyslogNetworkUDP, SyslogNetworkUnixgram:
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %q, expected one of %s",
+			ErrUnknownSyslogNetwork, network, strings.Join(SyslogNetworkNames, ", "),
+		)
+	}
+}
+
+func getSyslogFacility(facility string) (int, error) {
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q, expected one of %s",
+			ErrUnknownSyslogFacility, facility, strings.Join(SyslogFacilityNames, ", "),
+		)
+	}
+
+	return code, nil
+}
+
+// SyslogSeverity returns a syslog severity of the level, see RFC5424 section 6.2.1.
+func SyslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // informational
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // error
+	case zapcore.DPanicLevel:
+		return 2 // critical
+	case zapcore.PanicLevel:
+		return 1 // alert
+	case zapcore.FatalLevel:
+		return 0 // emergency
+	default:
+		return 5 // notice
+	}
+}
+
+// SyslogLogSinkOptions configures [SyslogLogSink].
+type SyslogLogSinkOptions struct {
+	Network  string
+	Address  string
+	Facility string
+	// AppName is the name of the executable if empty.
+	AppName string
+	// Hostname is the name of the host if empty.
+	Hostname string
+	// BufferSize is a number of entries waiting for sending, new entries are dropped if it is exceeded.
+	BufferSize int
+	// ReconnectInterval is a minimal interval between connection attempts after a failure.
+	ReconnectInterval time.Duration
+	// Now is used instead of [time.Now] for ReconnectInterval if it is not nil.
+	Now func() time.Time
+}
+
+// SyslogLogSink sends log entries to a syslog server in RFC5424 format in a background goroutine.
+//
+// Logging never blocks: if the buffer is full, entries are dropped and counted, see [SyslogLogSink.Dropped].
+// The connection is opened on the first entry and reopened after failures, at most once per ReconnectInterval.
+// Entries which cannot be sent are written to the fallback core (e.g. file core) if it is set.
+type SyslogLogSink struct {
+	options      SyslogLogSinkOptions
+	isFramed     bool
+	facility     int
+	header       string // HOSTNAME APP-NAME PROCID
+	fallback     zapcore.Core
+	entries      chan syslogLogEntry
+	syncRequests chan chan struct{}
+	stop         chan struct{}
+	stopped      chan struct{}
+	stopOnce     sync.Once
+	dropped      atomic.Uint64
+	reported     uint64
+
+	// used only by the background goroutine
+	conn        net.Conn
+	lastDialAt  time.Time
+	isConnected bool
+	isFailed    bool
+}
+
+// syslogLogEntry is an encoded entry with original entry and fields for writing to the fallback core.
+type syslogLogEntry struct {
+	entry   zapcore.Entry
+	fields  []zapcore.Field
+	message string
+}
+
+// NewSyslogLogSink creates a new [SyslogLogSink] and starts sending.
+// fallback may be nil, then entries which cannot be sent are dropped.
+// Entries are written to fallback only if it accepts them, messages of the sink are always written.
+// Use [SyslogLogSink.Close] to send remaining entries and stop.
+func NewSyslogLogSink(options SyslogLogSinkOptions, fallback zapcore.Core) (*SyslogLogSink, error) {
+	ew := tools.GetErrorWrapper("NewSyslogLogSink")
+
+	isFramed, err := getSyslogFraming(options.Network)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	facility, err := getSyslogFacility(options.Facility)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	if options.AppName == "" {
+		options.AppName = filepath.Base(os.Args[0])
+	}
+
+	if options.Hostname == "" {
+		options.Hostname, _ = os.Hostname()
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	s := &SyslogLogSink{
+		options:  options,
+		isFramed: isFramed,
+		facility: facility,
+		header: fmt.Sprintf("%s %s %d",
+			formatSyslogHeaderField(options.Hostname, 255),
+			formatSyslogHeaderField(options.AppName, 48),
+			os.Getpid(),
+		),
+		fallback:     fallback,
+		entries:      make(chan syslogLogEntry, options.BufferSize),
+		syncRequests: make(chan chan struct{}),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+// Core returns [zapcore.Core] which sends entries enabled by level to the sink.
+// Entries are encoded to MSG according to format, default encoder is json.
+func (s *SyslogLogSink) Core(level zapcore.LevelEnabler, format *LoggerFormatConfig) (zapcore.Core, error) {
+	ew := tools.GetErrorWrapper("SyslogLogSink.Core")
+
+	encoder, err := NewLoggerEncoder(format, LoggerEncoderJSON)
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	core, err := newEntryFormatCore(format, &syslogLogCore{LevelEnabler: level, sink: s, encoder: encoder})
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return core, nil
+}
+
+// Dropped returns a number of entries dropped because of buffer overflow or failed sending without fallback.
+func (s *SyslogLogSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Sync sends buffered entries and waits for the result.
+func (s *SyslogLogSink) Sync() error {
+	done := make(chan struct{})
+
+	select {
+	case s.syncRequests <- done:
+	case <-s.stopped:
+		return nil
+	case <-time.After(syslogLogSyncTimeout):
+		return tools.WrapMethodError(context.DeadlineExceeded, "SyslogLogSink.Sync")
+	}
+
+	<-done
+
+	return nil
+}
+
+// Close sends buffered entries, closes the connection and stops the sink.
+func (s *SyslogLogSink) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.stopped:
+	case <-time.After(syslogLogSyncTimeout):
+	}
+}
+
+func (s *SyslogLogSink) add(entry syslogLogEntry) {
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *SyslogLogSink) run() {
+	defer close(s.stopped)
+
+	defer func() {
+		if s.conn != nil {
+			_ = s.conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case entry := <-s.entries:
+			s.send(entry)
+		case done := <-s.syncRequests:
+			s.drain()
+			close(done)
+		case <-s.stop:
+			s.drain()
+			return
+		}
+	}
+}
+
+// drain sends all buffered entries.
+func (s *SyslogLogSink) drain() {
+	for {
+		select {
+		case entry := <-s.entries:
+			s.send(entry)
+		default:
+			return
+		}
+	}
+}
+
+func (s *SyslogLogSink) send(entry syslogLogEntry) {
+	err := s.write(s.formatMessage(entry))
+	if err != nil {
+		s.writeFallback(entry, err)
+	} else if s.isFailed {
+		s.isFailed = false
+		s.writeFallbackMessage(zapcore.InfoLevel, "Connection to syslog restored")
+	}
+
+	s.reportDropped()
+}
+
+// write sends data, a broken connection is reopened once immediately, since servers close idle connections.
+func (s *SyslogLogSink) write(data []byte) error {
+	err := s.writeToConnection(data)
+	if err == nil || !s.isConnected {
+		return err
+	}
+
+	s.closeConnection()
+	s.lastDialAt = time.Time{}
+
+	return s.writeToConnection(data)
+}
+
+func (s *SyslogLogSink) writeToConnection(data []byte) error {
+	if s.conn == nil {
+		if s.options.Now().Sub(s.lastDialAt) < s.options.ReconnectInterval {
+			return errSyslogReconnectDelayed
+		}
+
+		s.lastDialAt = s.options.Now()
+		s.isConnected = false
+
+		conn, err := net.DialTimeout(s.options.Network, s.options.Address, syslogWriteTimeout)
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		s.closeConnection()
+		return err
+	}
+
+	if _, err := s.conn.Write(data); err != nil {
+		s.closeConnection()
+		return err
+	}
+
+	s.isConnected = true
+
+	return nil
+}
+
+func (s *SyslogLogSink) closeConnection() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// formatMessage returns the entry in RFC5424 format, framed by octet counting for stream networks.
+func (s *SyslogLogSink) formatMessage(entry syslogLogEntry) []byte {
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	message := fmt.Sprintf("<%d>1 %s %s %s - %s",
+		s.facility*8+SyslogSeverity(entry.entry.Level),
+		entry.entry.Time.Format(syslogTimestampLayout),
+		s.header,
+		formatSyslogHeaderField(entry.entry.LoggerName, 32),
+		entry.message,
+	)
+
+	if s.isFramed {
+		message = strconv.Itoa(len(message)) + " " + message
+	}
+
+	return []byte(message)
+}
+
+// formatSyslogHeaderField replaces characters not allowed in header fields and truncates value to maxLength,
+// empty value is replaced with NILVALUE.
+func formatSyslogHeaderField(value string, maxLength int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+
+		return r
+	}, value)
+
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+func (s *SyslogLogSink) writeFallback(entry syslogLogEntry, err error) {
+	if s.fallback == nil {
+		s.dropped.Add(1)
+		return
+	}
+
+	// the failure is reported once until the connection is restored
+	if !s.isFailed {
+		s.isFailed = true
+		s.writeFallbackMessage(zapcore.ErrorLevel, "Failed to send log entry to syslog, writing to fallback",
+			zap.String("address", s.options.Address),
+			zap.Error(err),
+		)
+	}
+
+	writeCheckedEntry(s.fallback, entry.entry, entry.fields)
+}
+
+// reportDropped writes a warning to the fallback core if entries were dropped since the last report.
+func (s *SyslogLogSink) reportDropped() {
+	dropped := s.dropped.Load()
+	if dropped == s.reported || s.fallback == nil {
+		return
+	}
+
+	s.writeFallbackMessage(zapcore.WarnLevel, "Syslog log entries dropped",
+		zap.Uint64("dropped", dropped-s.reported),
+		zap.Uint64("dropped_total", dropped),
+	)
+
+	s.reported = dropped
+}
+
+// writeFallbackMessage writes a message of the sink to the fallback core without checking,
+// since the message is not passed to other cores of the logger, unlike entries which cannot be sent.
+func (s *SyslogLogSink) writeFallbackMessage(level zapcore.Level, message string, fields ...zapcore.Field) {
+	if s.fallback == nil {
+		return
+	}
+
+	_ = s.fallback.Write(zapcore.Entry{
+		Level:      level,
+		Time:       s.options.Now(),
+		LoggerName: "SyslogLogSink",
+		Message:    message,
+	}, fields)
+}
+
+// syslogLogCore encodes entries and passes them to the sink.
+type syslogLogCore struct {
+	zapcore.LevelEnabler
+	sink    *SyslogLogSink
+	encoder zapcore.Encoder
+	fields  []zapcore.Field
+}
+
+// With implements [zapcore.Core].
+func (c *syslogLogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	return &syslogLogCore{
+		LevelEnabler: c.LevelEnabler,
+		sink:         c.sink,
+		encoder:      encoder,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check implements [zapcore.Core].
+func (c *syslogLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *syslogLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return tools.WrapMethodError(err, "syslogLogCore.Write")
+	}
+
+	message := strings.TrimSuffix(buffer.String(), "\n")
+	buffer.Free()
+
+	c.sink.add(syslogLogEntry{
+		entry:   entry,
+		fields:  append(append([]zapcore.Field{}, c.fields...), fields...),
+		message: message,
+	})
+
+	return nil
+}
+
+// Sync implements [zapcore.Core].
+func (c *syslogLogCore) Sync() error {
+	return c.sink.Sync()
+}
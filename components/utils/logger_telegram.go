@@ -0,0 +1,458 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+)
+
+const (
+	// telegramLogMessageMaxLength is a limit of Telegram for a text message.
+	telegramLogMessageMaxLength = 4096
+	// telegramLogFieldMaxLength limits a value of a field in a message.
+	telegramLogFieldMaxLength = 500
+	// telegramLogCloseTimeout limits waiting for a message being sent in [TelegramLogSink.Close].
+	telegramLogCloseTimeout = 10 * time.Second
+)
+
+// ErrTelegramLogSenderNotSet is returned by [TelegramLogSenderProxy] until the bot is ready.
+var ErrTelegramLogSenderNotSet = errors.New("telegram log sender is not set")
+
+type LoggerTelegramConfig struct {
+	Level string
+	// DedupWindow is a period during which identical entries are sent once.
+	DedupWindow time.Duration
+	// RateLimit is a maximum number of messages per minute.
+	RateLimit int
+	// BufferSize is a number of entries waiting for sending, new entries are dropped if it is exceeded.
+	BufferSize int
+}
+
+// Validate checks configuration values.
+func (c *LoggerTelegramConfig) Validate(v *tools.ConfigValidator) {
+	validateZapLevel(v, c.Level)
+	v.Positive("dedup_window", int64(c.DedupWindow))
+	v.Positive("rate_limit", int64(c.RateLimit))
+	v.Positive("buffer_size", int64(c.BufferSize))
+}
+
+// TelegramLogSender sends text messages to admins.
+// Implementations must not log errors of sending, they are reported by [TelegramLogSink] to its fallback core.
+type TelegramLogSender interface {
+	SendLogAlert(text string) error
+}
+
+// TelegramLogSenderProxy passes messages to a sender which is set after the bot is created,
+// so the logger does not depend on the bot.
+type TelegramLogSenderProxy struct {
+	sender atomic.Pointer[TelegramLogSender]
+}
+
+// NewTelegramLogSenderProxy creates a proxy without a sender.
+// Using for configuring with wire.
+func NewTelegramLogSenderProxy() *TelegramLogSenderProxy {
+	return &TelegramLogSenderProxy{}
+}
+
+// SetSender sets the sender, nil removes it.
+func (p *TelegramLogSenderProxy) SetSender(sender TelegramLogSender) {
+	if sender == nil {
+		p.sender.Store(nil)
+		return
+	}
+
+	p.sender.Store(&sender)
+}
+
+// SendLogAlert implements [TelegramLogSender].
+// Returns [ErrTelegramLogSenderNotSet] if the sender is not set.
+func (p *TelegramLogSenderProxy) SendLogAlert(text string) error {
+	sender := p.sender.Load()
+	if sender == nil {
+		return ErrTelegramLogSenderNotSet
+	}
+
+	return (*sender).SendLogAlert(text)
+}
+
+// TelegramLogSinkOptions configures deduplication and rate limiting of [TelegramLogSink].
+type TelegramLogSinkOptions struct {
+	DedupWindow time.Duration
+	// RateLimit is a maximum number of messages per RateInterval.
+	RateLimit    int
+	RateInterval time.Duration
+	BufferSize   int
+	// RetryAfter is a pause of sending after a failure, entries are counted as suppressed during it.
+	RetryAfter time.Duration
+	// Now is used instead of [time.Now] if it is not nil.
+	Now func() time.Time
+	// Ticks triggers sending of summaries, a ticker with period of DedupWindow, at most a second, is used if it is nil.
+	// Buffered entries are processed before summaries are sent.
+	Ticks <-chan time.Time
+}
+
+// TelegramLogSink sends log entries to Telegram admins in a background goroutine.
+//
+// Identical entries (same level, logger, caller and message) are sent once per dedup window,
+// then the number of suppressed ones is sent in a summary or appended to the next message.
+// Entries exceeding the rate limit are suppressed the same way.
+// Logging never blocks: if the buffer is full, entries are dropped and counted, see [TelegramLogSink.Dropped].
+// Failures of sending are written to the fallback core only, so they are not sent again.
+type TelegramLogSink struct {
+	sender   TelegramLogSender
+	fallback zapcore.Core
+	options  TelegramLogSinkOptions
+	now      func() time.Time
+	alerts   chan telegramLogAlert
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+
+	// state of the sending goroutine
+	records     map[string]*telegramLogRecord
+	sentAt      []time.Time
+	pausedUntil time.Time
+	reported    uint64
+}
+
+// telegramLogAlert is a formatted entry waiting for sending.
+type telegramLogAlert struct {
+	key   string
+	title string
+	text  string
+}
+
+// telegramLogRecord tracks entries with the same key during the dedup window.
+type telegramLogRecord struct {
+	title      string
+	startedAt  time.Time
+	suppressed int
+}
+
+// NewTelegramLogSink creates a new [TelegramLogSink] and starts sending.
+// fallback may be nil, then failures of sending are not reported.
+// Use [TelegramLogSink.Close] to stop.
+func NewTelegramLogSink(
+	sender TelegramLogSender,
+	fallback zapcore.Core,
+	options TelegramLogSinkOptions,
+) *TelegramLogSink {
+	s := &TelegramLogSink{
+		sender:   sender,
+		fallback: fallback,
+		options:  options,
+		now:      options.Now,
+		alerts:   make(chan telegramLogAlert, options.BufferSize),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		records:  map[string]*telegramLogRecord{},
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Core returns [zapcore.Core] which sends entries enabled by level to the sink.
+func (s *TelegramLogSink) Core(level zapcore.LevelEnabler) zapcore.Core {
+	return &telegramLogCore{LevelEnabler: level, sink: s}
+}
+
+// Dropped returns a number of entries dropped because of buffer overflow.
+func (s *TelegramLogSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the sink, buffered entries and pending summaries are not sent.
+// A message being sent is waited for at most telegramLogCloseTimeout, so a stuck sender does not block shutdown.
+func (s *TelegramLogSink) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.stopped:
+	case <-time.After(telegramLogCloseTimeout):
+	}
+}
+
+func (s *TelegramLogSink) add(alert telegramLogAlert) {
+	select {
+	case s.alerts <- alert:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *TelegramLogSink) run() {
+	defer close(s.stopped)
+
+	ticks := s.options.Ticks
+	if ticks == nil {
+		ticker := time.NewTicker(min(s.options.DedupWindow, time.Second))
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case alert := <-s.alerts:
+			s.process(alert, s.now())
+		case <-ticks:
+			s.processBuffered()
+			s.sendSummaries(s.now())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// processBuffered processes entries waiting in the buffer, so they are counted in summaries.
+func (s *TelegramLogSink) processBuffered() {
+	for {
+		select {
+		case alert := <-s.alerts:
+			s.process(alert, s.now())
+		default:
+			return
+		}
+	}
+}
+
+func (s *TelegramLogSink) process(alert telegramLogAlert, now time.Time) {
+	record, ok := s.records[alert.key]
+	if ok && now.Sub(record.startedAt) < s.options.DedupWindow {
+		record.suppressed++
+		return
+	}
+
+	text := alert.text
+	previouslySuppressed := 0
+
+	if ok && record.suppressed > 0 {
+		previouslySuppressed = record.suppressed
+		text = fmt.Sprintf("%s\n\n%d similar errors suppressed", text, previouslySuppressed)
+	}
+
+	record = &telegramLogRecord{title: alert.title, startedAt: now}
+	s.records[alert.key] = record
+
+	if !s.send(text, now) {
+		record.suppressed = previouslySuppressed + 1
+	}
+}
+
+// sendSummaries sends numbers of suppressed entries of records with finished dedup window.
+func (s *TelegramLogSink) sendSummaries(now time.Time) {
+	for _, key := range tools.SortMapKeys(s.records) {
+		record := s.records[key]
+		if now.Sub(record.startedAt) < s.options.DedupWindow {
+			continue
+		}
+
+		if record.suppressed == 0 {
+			delete(s.records, key)
+			continue
+		}
+
+		// the record is kept until the summary is sent
+		if s.send(fmt.Sprintf("%d similar errors suppressed:\n%s", record.suppressed, record.title), now) {
+			delete(s.records, key)
+		}
+	}
+
+	s.reportDropped()
+}
+
+// send sends text if the rate limit allows it, returns false if the text is not sent.
+func (s *TelegramLogSink) send(text string, now time.Time) bool {
+	if now.Before(s.pausedUntil) {
+		return false
+	}
+
+	sentAt := s.sentAt[:0]
+	for _, at := range s.sentAt {
+		if now.Sub(at) < s.options.RateInterval {
+			sentAt = append(sentAt, at)
+		}
+	}
+
+	s.sentAt = sentAt
+	if len(s.sentAt) >= s.options.RateLimit {
+		return false
+	}
+
+	err := s.sender.SendLogAlert(text)
+	if errors.Is(err, ErrTelegramLogSenderNotSet) {
+		return false
+	}
+
+	if err != nil {
+		s.pausedUntil = now.Add(s.options.RetryAfter)
+		s.writeFallbackMessage(zapcore.ErrorLevel, "Failed to send log entry to Telegram",
+			zap.String("error", err.Error()),
+			zap.Duration("retry_after", s.options.RetryAfter),
+		)
+
+		return false
+	}
+
+	s.sentAt = append(s.sentAt, now)
+
+	return true
+}
+
+// reportDropped writes a warning to the fallback core if entries were dropped since the last report.
+func (s *TelegramLogSink) reportDropped() {
+	dropped := s.dropped.Load()
+	if dropped == s.reported {
+		return
+	}
+
+	s.writeFallbackMessage(zapcore.WarnLevel, "Telegram log entries dropped",
+		zap.Uint64("dropped", dropped-s.reported),
+		zap.Uint64("dropped_total", dropped),
+	)
+
+	s.reported = dropped
+}
+
+// writeFallbackMessage writes a message of the sink to the fallback core without checking,
+// since the message is not passed to other cores of the logger.
+func (s *TelegramLogSink) writeFallbackMessage(level zapcore.Level, message string, fields ...zapcore.Field) {
+	if s.fallback == nil {
+		return
+	}
+
+	_ = s.fallback.Write(zapcore.Entry{
+		Level:      level,
+		Time:       s.now(),
+		LoggerName: "TelegramLogSink",
+		Message:    message,
+	}, fields)
+}
+
+// telegramLogCore formats entries and passes them to the sink.
+type telegramLogCore struct {
+	zapcore.LevelEnabler
+	sink   *TelegramLogSink
+	fields []zapcore.Field
+}
+
+// With implements [zapcore.Core].
+func (c *telegramLogCore) With(fields []zapcore.Field) zapcore.Core {
+	return &telegramLogCore{
+		LevelEnabler: c.LevelEnabler,
+		sink:         c.sink,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+// Check implements [zapcore.Core].
+func (c *telegramLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+// Write implements [zapcore.Core].
+func (c *telegramLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	title := FormatTelegramLogTitle(entry)
+	caller := ""
+
+	if entry.Caller.Defined {
+		caller = entry.Caller.TrimmedPath()
+	}
+
+	c.sink.add(telegramLogAlert{
+		key:   strings.Join([]string{entry.Level.String(), entry.LoggerName, caller, entry.Message}, "\x00"),
+		title: title,
+		text:  FormatTelegramLogMessage(entry, encoder.Fields),
+	})
+
+	return nil
+}
+
+// Sync implements [zapcore.Core], entries are not waited for, so a broken Telegram does not block the logger.
+func (c *telegramLogCore) Sync() error {
+	return nil
+}
+
+// FormatTelegramLogTitle returns a short description of the entry, e.g. "ERROR StatManager: Failed to add stat".
+func FormatTelegramLogTitle(entry zapcore.Entry) string {
+	title := entry.Level.CapitalString()
+	if entry.LoggerName != "" {
+		title += " " + entry.LoggerName
+	}
+
+	return truncateTelegramLogText(title+": "+entry.Message, telegramLogFieldMaxLength)
+}
+
+// FormatTelegramLogMessage formats the entry as a plain text message for Telegram.
+// The message must be sent without parse mode, so any characters are safe.
+// Values of fields are truncated and stacktrace is omitted to fit Telegram limits.
+func FormatTelegramLogMessage(entry zapcore.Entry, fields map[string]interface{}) string {
+	builder := strings.Builder{}
+	builder.WriteString(FormatTelegramLogTitle(entry))
+
+	if entry.Caller.Defined {
+		builder.WriteString("\nat ")
+		builder.WriteString(entry.Caller.TrimmedPath())
+	}
+
+	if len(fields) > 0 {
+		builder.WriteString("\n")
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		builder.WriteString("\n")
+		builder.WriteString(key)
+		builder.WriteString(": ")
+		builder.WriteString(truncateTelegramLogText(stringifyLogFieldValue(fields[key]), telegramLogFieldMaxLength))
+	}
+
+	return truncateTelegramLogText(builder.String(), telegramLogMessageMaxLength)
+}
+
+// truncateTelegramLogText cuts text to maxLength runes, so it stays valid UTF-8.
+func truncateTelegramLogText(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	runes := []rune(text)
+
+	return string(runes[:maxLength-1]) + "…"
+}
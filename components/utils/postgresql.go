@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -12,10 +16,13 @@ import (
 )
 
 type PostgresqlConfig struct {
+	IsEnabled bool
+	// DSN is a full connection string (URL or key/value), it takes precedence over Host, Port, User, Password and Database.
+	DSN                tools.Secret
 	Host               string
 	Port               int
 	User               string
-	Password           string
+	Password           tools.Secret
 	Database           string
 	IsNeedToRecreate   bool
 	AutoMigrate        bool
@@ -25,6 +32,51 @@ type PostgresqlConfig struct {
 	MaxIdleConns       int
 	MaxOpenConns       int
 	IsDebug            bool
+	// ConnectRetry is a policy of retrying the first connection, nil means a single attempt.
+	ConnectRetry *tools.RetryConfig
+	// IsRecreateConfirmed is set after IsNeedToRecreate is confirmed, see [tools.ConfirmDestructiveAction].
+	// Migrate refuses to drop tables without it.
+	IsRecreateConfirmed bool
+}
+
+// Validate checks configuration values.
+func (c *PostgresqlConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	if c.DSN != "" {
+		_, err := pgconn.ParseConfig(c.DSN.Value())
+		v.Check(err == nil, "dsn", "invalid connection string: %s", maskedError(err))
+	} else {
+		v.Required("host", c.Host)
+		v.Port("port", c.Port)
+		v.Required("user", c.User)
+		v.Required("database", c.Database)
+	}
+
+	v.NotNegative("conn_max_lifetime", c.ConnMaxLifetime)
+	v.NotNegative("conn_max_idle_time", c.ConnMaxIdleTime)
+	v.NotNegative("max_idle_conns", int64(c.MaxIdleConns))
+	v.NotNegative("max_open_conns", int64(c.MaxOpenConns))
+
+	if c.ConnectRetry != nil {
+		c.ConnectRetry.Validate(v.Section("connect_retry"))
+	}
+}
+
+// GetDatabase returns name of the database from DSN or Database.
+func (c *PostgresqlConfig) GetDatabase() string {
+	if c.DSN == "" {
+		return c.Database
+	}
+
+	parsed, err := pgconn.ParseConfig(c.DSN.Value())
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Database
 }
 
 // Postgresql manipulates connection to Postgresql database.
@@ -50,7 +102,19 @@ func NewPostgresql(
 
 	ew := tools.GetErrorWrapper("NewPostgresql")
 
-	_, err := p.GetConnection()
+	if !config.IsEnabled {
+		p.logger.Info("Postgresql is disabled")
+		return p, func() {}, nil
+	}
+
+	// the database may start later than the application, e.g. in docker compose
+	err := tools.Retry(context.Background(), tools.RetryOptions{
+		Config: config.ConnectRetry,
+		Logger: p.logger.Named("Connect"),
+	}, func(context.Context) error {
+		_, err := p.GetConnection()
+		return err
+	})
 	if err != nil {
 		return nil, nil, ew(err)
 	}
@@ -68,17 +132,33 @@ func NewPostgresql(
 	}, nil
 }
 
+// IsEnabled reports whether Postgresql is enabled in config.
+// Methods of disabled component return [tools.ErrComponentDisabled].
+func (p *Postgresql) IsEnabled() bool {
+	return p.Config.IsEnabled
+}
+
 // GetConnectionString returns formated connection string.
+// Returns DSN from config as is if it is set.
 func (p *Postgresql) GetConnectionString() string {
+	if p.Config.DSN != "" {
+		return p.Config.DSN.Value()
+	}
+
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
 		p.Config.Host,
 		p.Config.User,
-		p.Config.Password,
+		p.Config.Password.Value(),
 		p.Config.Database,
 		p.Config.Port,
 	)
 }
 
+// GetMaskedConnectionString returns connection string with masked password, safe for logging.
+func (p *Postgresql) GetMaskedConnectionString() string {
+	return tools.MaskConnectionStringPassword(p.GetConnectionString())
+}
+
 // GetConnection create new connection with caching.
 // If connection is not cached, it will be created.
 //
@@ -87,17 +167,19 @@ func (p *Postgresql) GetConnection() (*gorm.DB, error) {
 	ew := p.ErrorWrapperCreator.GetMethodWrapper("GetConnection")
 	logger := p.logger.Named("GetConnection")
 
+	if !p.Config.IsEnabled {
+		return nil, ew(tools.NewErrComponentDisabled("postgresql"))
+	}
+
 	if p.db != nil {
 		return p.db, nil
 	}
 
-	dsn := p.GetConnectionString()
-
-	logger.Info("dsn", zap.String("dsn", dsn))
+	logger.Info("dsn", zap.String("dsn", p.GetMaskedConnectionString()))
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(p.GetConnectionString()), &gorm.Config{Logger: NewGormLogger(p.logger.Named("Gorm"))})
 	if err != nil {
-		return nil, ew(err)
+		return nil, ew(ClassifyPostgresqlError(err))
 	}
 
 	if p.Config.IsDebug {
@@ -121,6 +203,7 @@ func (p *Postgresql) GetConnection() (*gorm.DB, error) {
 
 // Migrate models to Postgresql.
 // Depends on Clickhouse.AutoMigrate parameter of [config.Config].
+// Tables are dropped only if IsNeedToRecreate is confirmed, see [PostgresqlConfig.IsRecreateConfirmed].
 func (p *Postgresql) Migrate(models []interface{}) error {
 	ew := p.ErrorWrapperCreator.GetMethodWrapper("Migrate")
 	logger := p.logger.Named("Migrate")
@@ -130,6 +213,13 @@ func (p *Postgresql) Migrate(models []interface{}) error {
 		return nil
 	}
 
+	if p.Config.IsNeedToRecreate && !p.Config.IsRecreateConfirmed {
+		err := fmt.Errorf("%w: recreate tables of postgresql database", tools.ErrDestructiveActionNotConfirmed)
+		logger.Error("Recreation of tables is not confirmed", zap.Error(err))
+
+		return ew(err)
+	}
+
 	db, err := p.GetConnection()
 	if err != nil {
 		logger.Error("Failed to connect to database", zap.Error(err))
@@ -141,14 +231,14 @@ func (p *Postgresql) Migrate(models []interface{}) error {
 			err := db.Migrator().DropTable(model)
 			if err != nil {
 				logger.Error("Failed to drop table", zap.Error(err))
-				return ew(err)
+				return ew(ClassifyPostgresqlError(err))
 			}
 		}
 
 		err = db.AutoMigrate(model)
 		if err != nil {
 			logger.Error("Failed to auto migrate", zap.Error(err), zap.Any("model", model))
-			return ew(err)
+			return ew(ClassifyPostgresqlError(err))
 		}
 	}
 
@@ -162,3 +252,54 @@ type BasicPostgresqlModel struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
+
+// postgresqlErrorClasses maps classes of SQLSTATE codes (the first two characters) to categories.
+var postgresqlErrorClasses = map[string]tools.ErrorCategory{
+	"08": tools.ErrorCategoryRetryable,    // connection exception
+	"53": tools.ErrorCategoryRetryable,    // insufficient resources
+	"57": tools.ErrorCategoryRetryable,    // operator intervention, e.g. shutdown
+	"58": tools.ErrorCategoryRetryable,    // system error
+	"40": tools.ErrorCategoryRetryable,    // transaction rollback, e.g. serialization failure or deadlock
+	"28": tools.ErrorCategoryForbidden,    // invalid authorization specification
+	"22": tools.ErrorCategoryInvalidInput, // data exception
+	"23": tools.ErrorCategoryInvalidInput, // integrity constraint violation
+}
+
+// postgresqlErrorCodes maps SQLSTATE codes to categories, they take precedence over [postgresqlErrorClasses].
+var postgresqlErrorCodes = map[string]tools.ErrorCategory{
+	"42501": tools.ErrorCategoryForbidden, // insufficient privilege
+	"3D000": tools.ErrorCategoryNotFound,  // invalid catalog name, the database does not exist
+}
+
+// ClassifyPostgresqlError classifies errors of pgx and gorm, see [tools.ClassifyError].
+// The code is "postgres.<SQLSTATE>" for server errors.
+func ClassifyPostgresqlError(err error) error {
+	var classifiedError *tools.ClassifiedError
+	if err == nil || errors.As(err, &classifiedError) {
+		return err
+	}
+
+	var pgError *pgconn.PgError
+
+	var connectError *pgconn.ConnectError
+
+	switch {
+	case errors.As(err, &pgError):
+		category, ok := postgresqlErrorCodes[pgError.Code]
+		if !ok {
+			category, ok = postgresqlErrorClasses[pgError.Code[:min(2, len(pgError.Code))]]
+		}
+
+		if !ok {
+			category = tools.ErrorCategoryPermanent
+		}
+
+		return tools.ClassifyError(err, category, "postgres."+pgError.Code, "")
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, gorm.ErrRecordNotFound):
+		return tools.ClassifyError(err, tools.ErrorCategoryNotFound, "postgres.no_rows", "")
+	case errors.As(err, &connectError), pgconn.SafeToRetry(err), pgconn.Timeout(err):
+		return tools.ClassifyError(err, tools.ErrorCategoryRetryable, "postgres.connection", "")
+	default:
+		return err
+	}
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"net"
+	"net/url"
 	"strconv"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -12,10 +15,31 @@ import (
 )
 
 type RabbitMQConfig struct {
+	IsEnabled bool
+	// URL is a full AMQP URI, it takes precedence over Host, Port, User and Password.
+	URL      tools.Secret
 	Host     string
 	Port     int
 	User     string
-	Password string
+	Password tools.Secret
+}
+
+// Validate checks configuration values.
+func (c *RabbitMQConfig) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	if c.URL != "" {
+		_, err := amqp.ParseURI(c.URL.Value())
+		v.Check(err == nil, "url", "invalid connection string: %s", maskedError(err))
+
+		return
+	}
+
+	v.Required("host", c.Host)
+	v.Port("port", c.Port)
+	v.Required("user", c.User)
 }
 
 // RabbitMQ manipulates RabbitMQ connections.
@@ -39,8 +63,29 @@ func NewRabbitMQ(
 	}
 }
 
+// IsEnabled reports whether RabbitMQ is enabled in config.
+// Methods of disabled component return [tools.ErrComponentDisabled].
+func (r *RabbitMQ) IsEnabled() bool {
+	return r.Config.IsEnabled
+}
+
 // GetConnectionString returns formated connection string.
+// If URL is set in config, it is used with vhost replaced by the given one (if it is not empty).
+// Query parameters of URL (heartbeat, connection_timeout, etc.) are kept.
 func (r *RabbitMQ) GetConnectionString(vhost string) string {
+	if r.Config.URL != "" {
+		uri, err := url.Parse(r.Config.URL.Value())
+		if err != nil || vhost == "" {
+			// URL is checked by validation, so error is unreachable for validated config
+			return r.Config.URL.Value()
+		}
+
+		uri.Path = "/" + vhost
+		uri.RawPath = "/" + url.PathEscape(vhost)
+
+		return uri.String()
+	}
+
 	hostAndPort := net.JoinHostPort(
 		r.Config.Host,
 		strconv.Itoa(r.Config.Port),
@@ -49,16 +94,85 @@ func (r *RabbitMQ) GetConnectionString(vhost string) string {
 	return fmt.Sprintf(
 		"amqp://%s:%s@%s/%s",
 		r.Config.User,
-		r.Config.Password,
+		r.Config.Password.Value(),
 		hostAndPort,
 		vhost,
 	)
 }
 
+// GetMaskedConnectionString returns connection string with masked password, safe for logging.
+func (r *RabbitMQ) GetMaskedConnectionString(vhost string) string {
+	return tools.MaskConnectionStringPassword(r.GetConnectionString(vhost))
+}
+
 // GetConnection create connection to RabbitMQ without caching.
 func (r *RabbitMQ) GetConnection(vhost string) (*amqp.Connection, error) {
 	ew := r.ErrorWrapperCreator.GetMethodWrapper("GetConnection")
+	logger := r.logger.Named("GetConnection")
+
+	if !r.Config.IsEnabled {
+		return nil, ew(tools.NewErrComponentDisabled("rabbitmq"))
+	}
+
+	logger.Info("url", zap.String("url", r.GetMaskedConnectionString(vhost)))
+
 	c, err := amqp.Dial(r.GetConnectionString(vhost))
 
-	return c, ew(err)
+	return c, ew(ClassifyRabbitMQError(err))
+}
+
+// RabbitMQDeliveryContext returns ctx carrying the correlation ID of the delivery.
+// A new ID is added if the publisher has not set it.
+func RabbitMQDeliveryContext(ctx context.Context, delivery amqp.Delivery) context.Context {
+	if delivery.CorrelationId != "" {
+		return tools.WithCorrelationID(ctx, delivery.CorrelationId)
+	}
+
+	ctx, _ = tools.EnsureCorrelationID(ctx)
+
+	return ctx
+}
+
+// SetRabbitMQCorrelationID sets the correlation ID of ctx to the publishing if it is not set yet,
+// so consumers continue the same trace, see [RabbitMQDeliveryContext].
+func SetRabbitMQCorrelationID(ctx context.Context, publishing *amqp.Publishing) {
+	if publishing.CorrelationId == "" {
+		publishing.CorrelationId = tools.CorrelationIDFromContext(ctx)
+	}
+}
+
+// ClassifyRabbitMQError classifies errors of amqp091, see [tools.ClassifyError].
+// The code is "rabbitmq.<reply code>", errors which the server allows to recover from are retryable.
+func ClassifyRabbitMQError(err error) error {
+	var classifiedError *tools.ClassifiedError
+	if err == nil || errors.As(err, &classifiedError) {
+		return err
+	}
+
+	var amqpError *amqp.Error
+	if !errors.As(err, &amqpError) {
+		if tools.IsRetryableError(err) {
+			return tools.ClassifyError(err, tools.ErrorCategoryRetryable, "rabbitmq.connection", "")
+		}
+
+		return err
+	}
+
+	code := fmt.Sprintf("rabbitmq.%d", amqpError.Code)
+
+	switch amqpError.Code {
+	case amqp.AccessRefused, amqp.NotAllowed:
+		return tools.ClassifyError(err, tools.ErrorCategoryForbidden, code, "")
+	case amqp.NotFound:
+		return tools.ClassifyError(err, tools.ErrorCategoryNotFound, code, "")
+	case amqp.PreconditionFailed, amqp.InvalidPath, amqp.ContentTooLarge, amqp.SyntaxError, amqp.CommandInvalid:
+		return tools.ClassifyError(err, tools.ErrorCategoryInvalidInput, code, "")
+	}
+
+	// the channel or the connection is closed, e.g. by the server restart
+	if amqpError.Recover || errors.Is(err, amqp.ErrClosed) || amqpError.Code == amqp.ConnectionForced {
+		return tools.ClassifyError(err, tools.ErrorCategoryRetryable, code, "")
+	}
+
+	return tools.ClassifyError(err, tools.ErrorCategoryPermanent, code, "")
 }
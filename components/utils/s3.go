@@ -2,21 +2,40 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
 
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
 )
 
 type S3Config struct {
+	IsEnabled        bool
 	ConfigPaths      []string
 	CredentialsPaths []string
 	ConfigFolder     string
 }
 
+// Validate checks configuration values.
+func (c *S3Config) Validate(v *tools.ConfigValidator) {
+	if !c.IsEnabled {
+		return
+	}
+
+	for i, path := range c.ConfigPaths {
+		v.Required(fmt.Sprintf("config_paths[%d]", i), path)
+	}
+
+	for i, path := range c.CredentialsPaths {
+		v.Required(fmt.Sprintf("credentials_paths[%d]", i), path)
+	}
+}
+
 // S3 manipulates connections to services like Amazon S3.
 type S3 struct {
 	Config              *S3Config
@@ -41,10 +60,20 @@ func NewS3(
 	}
 }
 
+// IsEnabled reports whether S3 is enabled in config.
+// Methods of disabled component return [tools.ErrComponentDisabled].
+func (s *S3) IsEnabled() bool {
+	return s.Config.IsEnabled
+}
+
 // GetClient creates a client without caching.
 func (s *S3) GetClient() (*s3.Client, error) {
 	ew := s.ErrorWrapperCreator.GetMethodWrapper("GetClient")
 
+	if !s.Config.IsEnabled {
+		return nil, ew(tools.NewErrComponentDisabled("s3"))
+	}
+
 	configPaths := []string{}
 	for _, path := range s.Config.ConfigPaths {
 		configPaths = append(configPaths, s.Config.ConfigFolder+string(os.PathSeparator)+path)
@@ -61,8 +90,67 @@ func (s *S3) GetClient() (*s3.Client, error) {
 		config.WithSharedCredentialsFiles(credentialsPaths),
 	)
 	if err != nil {
-		return nil, ew(err)
+		return nil, ew(ClassifyS3Error(err))
 	}
 
 	return s3.NewFromConfig(cfg), nil
 }
+
+// s3ErrorCodes maps error codes of AWS APIs to categories and user-safe messages.
+var s3ErrorCodes = map[string]struct {
+	category    tools.ErrorCategory
+	userMessage string
+}{
+	"NoSuchBucket":          {tools.ErrorCategoryNotFound, "Bucket does not exist"},
+	"NoSuchKey":             {tools.ErrorCategoryNotFound, "Object does not exist"},
+	"NotFound":              {tools.ErrorCategoryNotFound, ""},
+	"AccessDenied":          {tools.ErrorCategoryForbidden, ""},
+	"InvalidAccessKeyId":    {tools.ErrorCategoryForbidden, ""},
+	"SignatureDoesNotMatch": {tools.ErrorCategoryForbidden, ""},
+	"ExpiredToken":          {tools.ErrorCategoryForbidden, ""},
+	"InvalidBucketName":     {tools.ErrorCategoryInvalidInput, "Invalid bucket name"},
+	"InvalidArgument":       {tools.ErrorCategoryInvalidInput, ""},
+	"InvalidRequest":        {tools.ErrorCategoryInvalidInput, ""},
+	"KeyTooLongError":       {tools.ErrorCategoryInvalidInput, "Object key is too long"},
+	"EntityTooLarge":        {tools.ErrorCategoryInvalidInput, "Object is too large"},
+	"SlowDown":              {tools.ErrorCategoryRetryable, ""},
+	"ServiceUnavailable":    {tools.ErrorCategoryRetryable, ""},
+	"InternalError":         {tools.ErrorCategoryRetryable, ""},
+	"RequestTimeout":        {tools.ErrorCategoryRetryable, ""},
+	"ThrottlingException":   {tools.ErrorCategoryRetryable, ""},
+}
+
+// ClassifyS3Error classifies errors of AWS SDK, see [tools.ClassifyError].
+// The code is "s3.<API error code>", unknown API errors are classified by HTTP status.
+func ClassifyS3Error(err error) error {
+	var classifiedError *tools.ClassifiedError
+	if err == nil || errors.As(err, &classifiedError) {
+		return err
+	}
+
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		code := "s3." + apiError.ErrorCode()
+
+		if known, ok := s3ErrorCodes[apiError.ErrorCode()]; ok {
+			return tools.ClassifyError(err, known.category, code, known.userMessage)
+		}
+
+		var responseError interface{ HTTPStatusCode() int }
+		if errors.As(err, &responseError) {
+			return tools.ClassifyError(err, tools.GetHTTPStatusErrorCategory(responseError.HTTPStatusCode()), code, "")
+		}
+
+		if apiError.ErrorFault() == smithy.FaultServer {
+			return tools.ClassifyError(err, tools.ErrorCategoryRetryable, code, "")
+		}
+
+		return tools.ClassifyError(err, tools.ErrorCategoryPermanent, code, "")
+	}
+
+	if tools.IsRetryableError(err) {
+		return tools.ClassifyError(err, tools.ErrorCategoryRetryable, "s3.connection", "")
+	}
+
+	return err
+}
@@ -10,6 +10,7 @@ import (
 )
 
 type TelegramConfig struct {
+	IsEnabled  bool
 	LongPoller struct {
 		Timeout uint
 	}
@@ -36,11 +37,21 @@ func NewTelegram(
 	}
 }
 
+// IsEnabled reports whether Telegram is enabled in config.
+// Methods of disabled component return [tools.ErrComponentDisabled].
+func (t *TelegramBot) IsEnabled() bool {
+	return t.Config.IsEnabled
+}
+
 // CreateBot creates a new [telebot.Bot].
 func (t *TelegramBot) CreateBot(token string) (*telebot.Bot, error) {
 	ew := t.ErrorWrapperCreator.GetMethodWrapper("CreateBot")
 	logger := t.logger.Named("Start")
 
+	if !t.Config.IsEnabled {
+		return nil, ew(tools.NewErrComponentDisabled("telegram"))
+	}
+
 	settings := telebot.Settings{
 		Token: token,
 		Poller: &telebot.LongPoller{
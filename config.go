@@ -2,110 +2,235 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/roman-kart/go-initial-project/v2/components/managers"
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 	"os"
+	"regexp"
 	"time"
 )
 
+// ConfigEnvPrefix is a prefix of environment variables which override values of [Config],
+// e.g. APP_POSTGRESQL_PASSWORD or APP_TELEGRAM_ADMINS.
+const ConfigEnvPrefix = "APP"
+
+// ConfigProfileEnvName is a name of environment variable which selects config profile
+// if the profile is not passed to [NewConfig] explicitly.
+const ConfigProfileEnvName = "APP_ENV"
+
+// ErrInvalidConfigProfile is returned when a name of config profile is not allowed.
+var ErrInvalidConfigProfile = errors.New("invalid config profile")
+
+var configProfileRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ConfigProfile is a name of environment profile, e.g. "staging".
+// Values from main-<profile>.yaml override values from main.yaml.
+type ConfigProfile string
+
+// ConfigLoggerFormat is a format of a logger sink in [Config].
+type ConfigLoggerFormat struct {
+	Encoder         string `yaml:"encoder"`                            // json, console or logfmt, default depends on the sink
+	Color           bool   `default:"false"   yaml:"color"`            // colored levels, ignored by json encoder
+	TimeFormat      string `default:"iso8601" yaml:"time_format"`      // iso8601, rfc3339, rfc3339nano, epoch, epoch_millis, epoch_nanos or Go layout
+	CallerLevel     string `default:"debug"   yaml:"caller_level"`     // minimal level of entries with caller or none
+	StacktraceLevel string `default:"none"    yaml:"stacktrace_level"` // minimal level of entries with stacktrace or none
+}
+
+// ConfigLoggerRotation is a rotation of a logger file in [Config].
+type ConfigLoggerRotation struct {
+	IsEnabled    bool   `default:"false" yaml:"is_enabled"`
+	MaxSize      int    `default:"100"   yaml:"max_size"`       // megabytes, 0 - no rotation by size
+	MaxBackups   int    `default:"10"    yaml:"max_backups"`    // 0 - no limit
+	MaxAge       int    `default:"30"    yaml:"max_age"`        // days, 0 - no limit
+	MaxTotalSize int    `default:"0"     yaml:"max_total_size"` // megabytes of rotated files, 0 - no limit
+	LocalTime    bool   `default:"false" yaml:"local_time"`
+	Compress     bool   `default:"false" yaml:"compress"`
+	Schedule     string `default:"none"  yaml:"schedule"` // none, daily or hourly
+	Pattern      string `default:""      yaml:"pattern"`  // Go time layout of rotated file names, e.g. app-2006-01-02.log
+}
+
+// ConfigRetry is a retry policy of a component in [Config], see [tools.RetryConfig].
+type ConfigRetry struct {
+	MaxAttempts     int     `default:"0"     yaml:"max_attempts"`     // including the first one, 0 - no limit
+	InitialInterval uint    `default:"500"   yaml:"initial_interval"` // milliseconds
+	MaxInterval     uint    `default:"10000" yaml:"max_interval"`     // milliseconds
+	Multiplier      float64 `default:"2"     yaml:"multiplier"`
+	Jitter          float64 `default:"0.2"   yaml:"jitter"`           // randomized part of intervals, 0 - 1
+	MaxElapsedTime  uint    `default:"30"    yaml:"max_elapsed_time"` // seconds, 0 - no limit
+}
+
+// ConfigLoggerFile is an additional logger file in [Config].
+// Defaults are not applied to items of lists, so empty format values mean defaults of the logger.
+type ConfigLoggerFile struct {
+	Name     string               `yaml:"name"` // level is changed at runtime by sink name file.<name>
+	Level    string               `yaml:"level"`
+	Path     string               `yaml:"path"`
+	Rotation ConfigLoggerRotation `yaml:"rotation"`
+	Format   ConfigLoggerFormat   `yaml:"format"` // default encoder is json
+}
+
 // Config holds the application configuration.
 type Config struct {
-	ConfigFolder string `yaml:"-"`
+	ConfigFolder string        `yaml:"-"`
+	Profile      ConfigProfile `yaml:"-"`
 	Clickhouse   struct {
-		Host               string `default:"localhost" yaml:"host"`
-		Port               int    `default:"9000"      yaml:"port"`
-		User               string `default:"default"   yaml:"user"`
-		Password           string `default:""          yaml:"password"`
-		Database           string `default:"default"   yaml:"database"`
-		IsNeedToRecreate   bool   `default:"false"     yaml:"is_need_to_recreate"`
-		AutoMigrate        bool   `default:"false"     yaml:"auto_migrate"`
-		IsNeedToInitialize bool   `default:"false"     yaml:"is_need_to_initialize"`
-		ConnMaxLifetime    int64  `default:"60"        yaml:"conn_max_lifetime"`  // seconds
-		ConnMaxIdleTime    int64  `default:"60"        yaml:"conn_max_idle_time"` // seconds
-		MaxIdleConns       int    `default:"10"        yaml:"max_idle_conns"`
-		MaxOpenConns       int    `default:"10"        yaml:"max_open_conns"`
+		IsEnabled          bool         `default:"true"      yaml:"is_enabled"`
+		DSN                tools.Secret `yaml:"dsn"` // takes precedence over host, port, user, password and database
+		Host               string       `default:"localhost" yaml:"host"`
+		Port               int          `default:"9000"      yaml:"port"`
+		User               string       `default:"default"   yaml:"user"`
+		Password           tools.Secret `default:""          yaml:"password"`
+		Database           string       `default:"default"   yaml:"database"`
+		IsNeedToRecreate   bool         `default:"false"     yaml:"is_need_to_recreate"`
+		AutoMigrate        bool         `default:"false"     yaml:"auto_migrate"`
+		IsNeedToInitialize bool         `default:"false"     yaml:"is_need_to_initialize"`
+		ConnMaxLifetime    int64        `default:"60"        yaml:"conn_max_lifetime"`  // seconds
+		ConnMaxIdleTime    int64        `default:"60"        yaml:"conn_max_idle_time"` // seconds
+		MaxIdleConns       int          `default:"10"        yaml:"max_idle_conns"`
+		MaxOpenConns       int          `default:"10"        yaml:"max_open_conns"`
+		ConnectRetry       ConfigRetry  `yaml:"connect_retry"` // the first connection on start
+
+		IsRecreateConfirmed bool `yaml:"-"` // set by NewConfig after confirmation of is_need_to_recreate
 	} `yaml:"clickhouse"`
 	Logger struct {
 		Console struct {
-			IsEnabled bool   `default:"false" yaml:"is_enabled"`
-			Level     string `default:"info"  yaml:"level"`
+			IsEnabled bool               `default:"false"  yaml:"is_enabled"`
+			Level     string             `default:"info"   yaml:"level"`
+			Output    string             `default:"stdout" yaml:"output"` // stdout or stderr
+			Format    ConfigLoggerFormat `yaml:"format"`                  // default encoder is console
 		}
 		File struct {
-			IsEnabled bool   `default:"false"           yaml:"is_enabled"`
-			Level     string `default:"info"            yaml:"level"`
-			Path      string `default:"tmp/log/app.log" yaml:"path"`
-			Rotation  struct {
-				IsEnabled  bool `default:"false" yaml:"is_enabled"`
-				MaxSize    int  `default:"100"   yaml:"max_size"`
-				MaxBackups int  `default:"10"    yaml:"max_backups"`
-				MaxAge     int  `default:"30"    yaml:"max_age"`
-				LocalTime  bool `default:"false" yaml:"local_time"`
-				Compress   bool `default:"false" yaml:"compress"`
-			}
+			IsEnabled bool                 `default:"false"           yaml:"is_enabled"`
+			Level     string               `default:"info"            yaml:"level"`
+			Path      string               `default:"tmp/log/app.log" yaml:"path"`
+			Rotation  ConfigLoggerRotation `yaml:"rotation"`
+			Format    ConfigLoggerFormat   `yaml:"format"` // default encoder is json
+		}
+		Files      []ConfigLoggerFile `yaml:"files"` // additional file sinks, e.g. errors.log with error level
+		ClickHouse struct {
+			IsEnabled     bool   `default:"false" yaml:"is_enabled"`
+			Level         string `default:"info"  yaml:"level"`
+			Table         string `default:"logs"  yaml:"table"`
+			FlushInterval uint   `default:"5"     yaml:"flush_interval"` // seconds
+			BatchSize     int    `default:"1000"  yaml:"batch_size"`
+			BufferSize    int    `default:"10000" yaml:"buffer_size"`
+		} `yaml:"clickhouse"` // connection is taken from clickhouse section
+		Telegram struct {
+			IsEnabled   bool   `default:"false" yaml:"is_enabled"`
+			Level       string `default:"error" yaml:"level"`
+			DedupWindow uint   `default:"300"   yaml:"dedup_window"` // seconds
+			RateLimit   int    `default:"10"    yaml:"rate_limit"`   // messages per minute
+			BufferSize  int    `default:"100"   yaml:"buffer_size"`
+		} `yaml:"telegram"` // entries are sent to telegram.admins by the bot
+		Syslog struct {
+			IsEnabled         bool               `default:"false"         yaml:"is_enabled"`
+			Level             string             `default:"info"          yaml:"level"`
+			Network           string             `default:"udp"           yaml:"network"` // udp, tcp, unix or unixgram
+			Address           string             `default:"127.0.0.1:514" yaml:"address"` // host:port or socket path, e.g. /dev/log
+			Facility          string             `default:"user"          yaml:"facility"`
+			AppName           string             `default:""              yaml:"app_name"` // default is the name of the executable
+			Hostname          string             `default:""              yaml:"hostname"` // default is the name of the host
+			BufferSize        int                `default:"1000"          yaml:"buffer_size"`
+			ReconnectInterval uint               `default:"5"             yaml:"reconnect_interval"` // seconds
+			Format            ConfigLoggerFormat `yaml:"format"`                                     // default encoder is json
+		} `yaml:"syslog"`
+		Overrides map[string]string `yaml:"overrides"` // levels by logger name prefix, e.g. ClickHouse: debug
+		Redaction struct {
+			FieldNames []string `yaml:"field_names"` // added to password, token, dsn, secret and others
+			Patterns   []string `yaml:"patterns"`    // regular expressions, matches are masked in all strings
 		}
 	}
 	Postgresql struct {
-		Host               string `default:"localhost"   yaml:"host"`
-		Port               int    `default:"5432"        yaml:"port"`
-		User               string `default:"postgres"    yaml:"user"`
-		Password           string `default:"postgres"    yaml:"password"`
-		Database           string `default:"lucky-gamer" yaml:"database"`
-		IsNeedToRecreate   bool   `default:"false"       yaml:"is_need_to_recreate"`
-		AutoMigrate        bool   `default:"false"       yaml:"auto_migrate"`
-		IsNeedToInitialize bool   `default:"false"       yaml:"is_need_to_initialize"`
-		ConnMaxLifetime    int64  `default:"60"          yaml:"conn_max_lifetime"`  // seconds
-		ConnMaxIdleTime    int64  `default:"60"          yaml:"conn_max_idle_time"` // seconds
-		MaxIdleConns       int    `default:"10"          yaml:"max_idle_conns"`
-		MaxOpenConns       int    `default:"10"          yaml:"max_open_conns"`
+		IsEnabled          bool         `default:"true"        yaml:"is_enabled"`
+		DSN                tools.Secret `yaml:"dsn"` // takes precedence over host, port, user, password and database
+		Host               string       `default:"localhost"   yaml:"host"`
+		Port               int          `default:"5432"        yaml:"port"`
+		User               string       `default:"postgres"    yaml:"user"`
+		Password           tools.Secret `default:"postgres"    yaml:"password"`
+		Database           string       `default:"lucky-gamer" yaml:"database"`
+		IsNeedToRecreate   bool         `default:"false"       yaml:"is_need_to_recreate"`
+		AutoMigrate        bool         `default:"false"       yaml:"auto_migrate"`
+		IsNeedToInitialize bool         `default:"false"       yaml:"is_need_to_initialize"`
+		ConnMaxLifetime    int64        `default:"60"          yaml:"conn_max_lifetime"`  // seconds
+		ConnMaxIdleTime    int64        `default:"60"          yaml:"conn_max_idle_time"` // seconds
+		MaxIdleConns       int          `default:"10"          yaml:"max_idle_conns"`
+		MaxOpenConns       int          `default:"10"          yaml:"max_open_conns"`
+		ConnectRetry       ConfigRetry  `yaml:"connect_retry"` // the first connection on start
+
+		IsRecreateConfirmed bool `yaml:"-"` // set by NewConfig after confirmation of is_need_to_recreate
 	} `yaml:"postgresql"`
-	IsDebug  bool `default:"false"   yaml:"is_debug"`
-	Telegram struct {
-		Token      string  `yaml:"token"`
-		Admins     []int64 `yaml:"admins"`
+	IsDebug      bool `default:"false" yaml:"is_debug"`
+	IsProduction bool `default:"false" yaml:"is_production"` // forbids destructive actions
+	Telegram     struct {
+		IsEnabled  bool         `default:"true"      yaml:"is_enabled"`
+		Token      tools.Secret `yaml:"token"`
+		Admins     []int64      `yaml:"admins"`
 		LongPoller struct {
 			Timeout uint `default:"10" yaml:"timeout"`
 		} `yaml:"long_poller"`
 	} `yaml:"telegram"`
 	RabbitMQ struct {
-		Host     string `default:"localhost" yaml:"host"`
-		Port     int    `default:"5672"      yaml:"port"`
-		User     string `default:"guest"     yaml:"user"`
-		Password string `default:"guest"     yaml:"password"`
+		IsEnabled bool         `default:"true"      yaml:"is_enabled"`
+		URL       tools.Secret `yaml:"url"` // takes precedence over host, port, user and password
+		Host      string       `default:"localhost" yaml:"host"`
+		Port      int          `default:"5672"      yaml:"port"`
+		User      string       `default:"guest"     yaml:"user"`
+		Password  tools.Secret `default:"guest"     yaml:"password"`
 	} `yaml:"rabbitmq"`
 	S3 struct {
+		IsEnabled bool `default:"true" yaml:"is_enabled"` // also enables s3_manager
 		// paths from root
 		ConfigPaths      []string `yaml:"config_paths"`
 		CredentialsPaths []string `yaml:"credentials_paths"`
 	} `yaml:"s3"`
 	S3Manager struct {
-		Timeout uint   `default:"10"   yaml:"timeout"`
-		Bucket  string `yaml:"bucket"`
-		MaxKeys int32  `default:"1000" yaml:"max_keys"`
+		Timeout      uint        `default:"10"   yaml:"timeout"`
+		Bucket       string      `yaml:"bucket"`
+		MaxKeys      int32       `default:"1000" yaml:"max_keys"`
+		ConnectRetry ConfigRetry `yaml:"connect_retry"` // the connection check on start
 	} `yaml:"s3_manager"`
+	AdminHTTP struct {
+		IsEnabled bool         `default:"false"          yaml:"is_enabled"`
+		Address   string       `default:"127.0.0.1:8081" yaml:"address"`
+		Token     tools.Secret `yaml:"token"` // required in "Authorization: Bearer <token>" header
+	} `yaml:"admin_http"`
+	ConfigReload struct {
+		IsEnabled bool `default:"false" yaml:"is_enabled"`
+		Interval  uint `default:"5"     yaml:"interval"` // seconds
+	} `yaml:"config_reload"`
+	CircuitBreaker struct {
+		IsEnabled        bool `default:"true" yaml:"is_enabled"`
+		FailureThreshold int  `default:"5"    yaml:"failure_threshold"`   // consecutive failures which open a breaker
+		OpenTimeout      uint `default:"30"   yaml:"open_timeout"`        // seconds before trial calls
+		HalfOpenMaxCalls int  `default:"1"    yaml:"half_open_max_calls"` // successful trial calls which close a breaker
+	} `yaml:"circuit_breaker"` // calls of managers to clickhouse, postgresql and s3
 }
 
 // NewConfig creates a new config.
 // Using for read configuration from config files.
-func NewConfig(configFolder string, configCountdownSecondsCount uint) (*Config, error) {
-	configPath := configFolder +
-		string(os.PathSeparator) + "main.yaml"
-	localConfigPath := configFolder +
-		string(os.PathSeparator) + "main-local.yaml"
+//
+// Layers are merged in order: default tags, main.yaml, main-<profile>.yaml, main-local.yaml, environment variables.
+// Profile and local files are optional.
+// If profile is empty, it is taken from APP_ENV environment variable, empty profile means no profile layer.
+func NewConfig(configFolder string, profile ConfigProfile, configCountdownSecondsCount uint) (*Config, error) {
+	profile, err := resolveConfigProfile(profile)
+	if err != nil {
+		return nil, fmt.Errorf("NewConfig: %w", err)
+	}
 
-	configPaths := []string{
-		localConfigPath,
-		configPath,
+	config, loadResult, err := loadConfig(configFolder, profile)
+	if err != nil {
+		return nil, fmt.Errorf("NewConfig: %w", err)
 	}
 
-	config := Config{
-		ConfigFolder: configFolder,
+	for _, file := range loadResult.Files {
+		fmt.Printf("Config file loaded: %s\n", file)
 	}
 
-	err := tools.LoadConfig(configPaths, &config)
-	if err != nil {
-		return nil, fmt.Errorf("NewConfig: %w", err)
+	for _, override := range loadResult.EnvOverrides {
+		fmt.Printf("Config value %s is overridden by environment variable %s\n", override.Path, override.EnvName)
 	}
 
 	if config.IsDebug {
@@ -115,10 +240,10 @@ func NewConfig(configFolder string, configCountdownSecondsCount uint) (*Config,
 	}
 
 	alertsForProperties := map[string]bool{
-		"Enable recreation of clickhouse - TABLES WILL BE DELETED THAT CREATED":   config.Clickhouse.IsNeedToRecreate,
-		"Enable auto migrate of clickhouse - TABLE WILL BE ALTERED AUTOMATICALLY": config.Clickhouse.AutoMigrate,
-		"Enable recreation of postgresql - TABLES WILL BE DELETED THAT CREATED":   config.Postgresql.IsNeedToRecreate,
-		"Enable auto migrate of postgresql - TABLE WILL BE ALTERED AUTOMATICALLY": config.Postgresql.AutoMigrate,
+		"Enable recreation of clickhouse - TABLES WILL BE DELETED THAT CREATED":   config.Clickhouse.IsEnabled && config.Clickhouse.IsNeedToRecreate,
+		"Enable auto migrate of clickhouse - TABLE WILL BE ALTERED AUTOMATICALLY": config.Clickhouse.IsEnabled && config.Clickhouse.AutoMigrate,
+		"Enable recreation of postgresql - TABLES WILL BE DELETED THAT CREATED":   config.Postgresql.IsEnabled && config.Postgresql.IsNeedToRecreate,
+		"Enable auto migrate of postgresql - TABLE WILL BE ALTERED AUTOMATICALLY": config.Postgresql.IsEnabled && config.Postgresql.AutoMigrate,
 	}
 
 	for message, needToDisplay := range alertsForProperties {
@@ -127,25 +252,186 @@ func NewConfig(configFolder string, configCountdownSecondsCount uint) (*Config,
 		}
 	}
 
+	err = confirmDestructiveActions(config)
+	if err != nil {
+		return nil, fmt.Errorf("NewConfig: %w", err)
+	}
+
 	if configCountdownSecondsCount > 0 {
 		tools.CountdownCmd(context.Background(), "CHECK CONFIG", time.Second, configCountdownSecondsCount)
 	}
 
-	return &config, err
+	return config, nil
+}
+
+// confirmDestructiveActions requires confirmation of the enabled destructive flags.
+// Recreation of tables is confirmed by the database name in APP_CONFIRM_RECREATE_CLICKHOUSE
+// or APP_CONFIRM_RECREATE_POSTGRESQL, or by typing it if stdin is a terminal.
+// Recreation is always refused if IsProduction is set.
+// Disabled databases are skipped.
+// Confirmed recreation is marked by IsRecreateConfirmed, Migrate of databases drops tables only if it is set.
+func confirmDestructiveActions(config *Config) error {
+	type confirmation struct {
+		action      tools.DestructiveAction
+		isConfirmed *bool
+	}
+
+	confirmations := []confirmation{}
+
+	if config.Clickhouse.IsEnabled && config.Clickhouse.IsNeedToRecreate && config.Clickhouse.AutoMigrate {
+		database := NewClickHouseConfig(config).GetDatabase()
+		confirmations = append(confirmations, confirmation{
+			action: tools.DestructiveAction{
+				Description:         fmt.Sprintf("recreate tables of clickhouse database %q", database),
+				Confirmation:        database,
+				ConfirmationEnvName: ConfigEnvPrefix + "_CONFIRM_RECREATE_CLICKHOUSE",
+			},
+			isConfirmed: &config.Clickhouse.IsRecreateConfirmed,
+		})
+	}
+
+	if config.Postgresql.IsEnabled && config.Postgresql.IsNeedToRecreate && config.Postgresql.AutoMigrate {
+		database := NewPostgresqlConfig(config).GetDatabase()
+		confirmations = append(confirmations, confirmation{
+			action: tools.DestructiveAction{
+				Description:         fmt.Sprintf("recreate tables of postgresql database %q", database),
+				Confirmation:        database,
+				ConfirmationEnvName: ConfigEnvPrefix + "_CONFIRM_RECREATE_POSTGRESQL",
+			},
+			isConfirmed: &config.Postgresql.IsRecreateConfirmed,
+		})
+	}
+
+	for _, c := range confirmations {
+		if err := tools.ConfirmDestructiveAction(c.action, config.IsProduction); err != nil {
+			return fmt.Errorf("confirmDestructiveActions: %w", err)
+		}
+
+		*c.isConfirmed = true
+	}
+
+	return nil
+}
+
+// resolveConfigProfile returns profile or value of APP_ENV if profile is empty.
+func resolveConfigProfile(profile ConfigProfile) (ConfigProfile, error) {
+	if profile == "" {
+		profile = ConfigProfile(os.Getenv(ConfigProfileEnvName))
+	}
+
+	if profile == "" {
+		return "", nil
+	}
+
+	if profile == "local" || !configProfileRegexp.MatchString(string(profile)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidConfigProfile, profile)
+	}
+
+	return profile, nil
+}
+
+// getConfigFiles returns config files in configFolder in order of loading.
+func getConfigFiles(configFolder string, profile ConfigProfile) []tools.ConfigFile {
+	files := []tools.ConfigFile{
+		{Path: configFolder + string(os.PathSeparator) + "main.yaml", IsOptional: false},
+	}
+
+	if profile != "" {
+		files = append(files, tools.ConfigFile{
+			Path:       configFolder + string(os.PathSeparator) + "main-" + string(profile) + ".yaml",
+			IsOptional: true,
+		})
+	}
+
+	return append(files, tools.ConfigFile{
+		Path:       configFolder + string(os.PathSeparator) + "main-local.yaml",
+		IsOptional: true,
+	})
+}
+
+// getConfigPaths returns paths of all config files which may be loaded, including absent optional ones.
+func getConfigPaths(configFolder string, profile ConfigProfile) []string {
+	paths := []string{}
+	for _, file := range getConfigFiles(configFolder, profile) {
+		paths = append(paths, file.Path)
+	}
+
+	return paths
+}
+
+// loadConfig reads config files from configFolder, applies environment overrides and validates the result.
+func loadConfig(configFolder string, profile ConfigProfile) (*Config, *tools.ConfigLoadResult, error) {
+	config := Config{
+		ConfigFolder: configFolder,
+		Profile:      profile,
+	}
+
+	loadResult, err := tools.LoadConfig(getConfigFiles(configFolder, profile), ConfigEnvPrefix, &config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loadConfig: %w", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("loadConfig: %w", err)
+	}
+
+	return &config, loadResult, nil
+}
+
+// Validate checks all sections of the config and configs derived from it.
+// Returns [tools.ConfigValidationErrors] with every problem found.
+func (c *Config) Validate() error {
+	v := tools.NewConfigValidator()
+
+	NewClickHouseConfig(c).Validate(v.Section("clickhouse"))
+	NewPostgresqlConfig(c).Validate(v.Section("postgresql"))
+	NewLoggerConfig(c).Validate(v.Section("logger"))
+	// path of derived config is resolved from the root, so empty value is checked here
+	if c.Logger.File.IsEnabled {
+		v.Section("logger.file").Required("path", c.Logger.File.Path)
+	}
+
+	if c.Logger.ClickHouse.IsEnabled {
+		v.Section("logger.clickhouse").Check(c.Clickhouse.IsEnabled, "is_enabled", "requires clickhouse.is_enabled")
+	}
+
+	if c.Logger.Telegram.IsEnabled {
+		v.Section("logger.telegram").Check(c.Telegram.IsEnabled, "is_enabled", "requires telegram.is_enabled")
+	}
+
+	NewRabbitMQConfig(c).Validate(v.Section("rabbitmq"))
+	NewS3Config(c).Validate(v.Section("s3"))
+	NewS3ManagerConfig(c).Validate(v.Section("s3_manager"))
+	NewTelegramBotManagerConfig(c).Validate(v.Section("telegram"))
+	NewAdminHTTPConfig(c).Validate(v.Section("admin_http"))
+
+	if c.ConfigReload.IsEnabled {
+		v.Section("config_reload").Positive("interval", int64(c.ConfigReload.Interval))
+	}
+
+	if circuitBreakerConfig := NewCircuitBreakerConfig(c); circuitBreakerConfig != nil {
+		circuitBreakerConfig.Validate(v.Section("circuit_breaker"))
+	}
+
+	return v.Err()
 }
 
 func NewS3ManagerConfig(config *Config) *managers.S3ManagerConfig {
 	return &managers.S3ManagerConfig{
-		Timeout: config.S3Manager.Timeout,
-		Bucket:  config.S3Manager.Bucket,
-		MaxKeys: config.S3Manager.MaxKeys,
+		IsEnabled:    config.S3.IsEnabled,
+		Timeout:      config.S3Manager.Timeout,
+		Bucket:       config.S3Manager.Bucket,
+		MaxKeys:      config.S3Manager.MaxKeys,
+		ConnectRetry: newRetryConfig(config.S3Manager.ConnectRetry),
 	}
 }
 
 func NewTelegramBotManagerConfig(config *Config) *managers.TelegramBotManagerConfig {
 	return &managers.TelegramBotManagerConfig{
-		Token:  config.Telegram.Token,
-		Admins: config.Telegram.Admins,
+		IsEnabled: config.Telegram.IsEnabled,
+		Token:     config.Telegram.Token,
+		Admins:    config.Telegram.Admins,
 		LongPoller: struct {
 			Timeout uint
 		}{
@@ -156,19 +442,23 @@ func NewTelegramBotManagerConfig(config *Config) *managers.TelegramBotManagerCon
 
 func NewClickHouseConfig(config *Config) *utils.ClickHouseConfig {
 	return &utils.ClickHouseConfig{
-		Host:               config.Clickhouse.Host,
-		Port:               config.Clickhouse.Port,
-		User:               config.Clickhouse.User,
-		Password:           config.Clickhouse.Password,
-		Database:           config.Clickhouse.Database,
-		IsNeedToRecreate:   config.Clickhouse.IsNeedToRecreate,
-		AutoMigrate:        config.Clickhouse.AutoMigrate,
-		IsNeedToInitialize: config.Clickhouse.IsNeedToInitialize,
-		ConnMaxLifetime:    config.Clickhouse.ConnMaxLifetime,
-		ConnMaxIdleTime:    config.Clickhouse.ConnMaxIdleTime,
-		MaxIdleConns:       config.Clickhouse.MaxIdleConns,
-		MaxOpenConns:       config.Clickhouse.MaxOpenConns,
-		IsDebug:            config.IsDebug,
+		IsEnabled:           config.Clickhouse.IsEnabled,
+		DSN:                 config.Clickhouse.DSN,
+		Host:                config.Clickhouse.Host,
+		Port:                config.Clickhouse.Port,
+		User:                config.Clickhouse.User,
+		Password:            config.Clickhouse.Password,
+		Database:            config.Clickhouse.Database,
+		IsNeedToRecreate:    config.Clickhouse.IsNeedToRecreate,
+		AutoMigrate:         config.Clickhouse.AutoMigrate,
+		IsNeedToInitialize:  config.Clickhouse.IsNeedToInitialize,
+		ConnMaxLifetime:     config.Clickhouse.ConnMaxLifetime,
+		ConnMaxIdleTime:     config.Clickhouse.ConnMaxIdleTime,
+		MaxIdleConns:        config.Clickhouse.MaxIdleConns,
+		MaxOpenConns:        config.Clickhouse.MaxOpenConns,
+		IsDebug:             config.IsDebug,
+		ConnectRetry:        newRetryConfig(config.Clickhouse.ConnectRetry),
+		IsRecreateConfirmed: config.Clickhouse.IsRecreateConfirmed,
 	}
 }
 
@@ -176,63 +466,159 @@ func NewLoggerConfig(config *Config) *utils.LoggerConfig {
 	var loggerConsoleConfig *utils.LoggerConsoleConfig
 	if config.Logger.Console.IsEnabled {
 		loggerConsoleConfig = &utils.LoggerConsoleConfig{
-			Level: config.Logger.Console.Level,
+			Level:  config.Logger.Console.Level,
+			Output: config.Logger.Console.Output,
+			Format: newLoggerFormatConfig(config.Logger.Console.Format),
 		}
 	}
 
 	var loggerFileConfig *utils.LoggerFileConfig
 	if config.Logger.File.IsEnabled {
 		loggerFileConfig = &utils.LoggerFileConfig{
-			Level: config.Logger.File.Level,
-			Path:  tools.GetPathFromRoot(config.Logger.File.Path),
+			Level:    config.Logger.File.Level,
+			Path:     tools.GetPathFromRoot(config.Logger.File.Path),
+			Rotation: newLoggerRotationConfig(config.Logger.File.Rotation),
+			Format:   newLoggerFormatConfig(config.Logger.File.Format),
 		}
+	}
 
-		if config.Logger.File.Rotation.IsEnabled {
-			loggerFileConfig.Rotation = &utils.LoggerRotationConfig{
-				MaxSize:    config.Logger.File.Rotation.MaxSize,
-				MaxAge:     config.Logger.File.Rotation.MaxAge,
-				MaxBackups: config.Logger.File.Rotation.MaxBackups,
-				Localtime:  config.Logger.File.Rotation.LocalTime,
-				Compress:   config.Logger.File.Rotation.Compress,
-			}
+	loggerFilesConfigs := []*utils.LoggerFileConfig{}
+	for _, file := range config.Logger.Files {
+		loggerFilesConfigs = append(loggerFilesConfigs, &utils.LoggerFileConfig{
+			Name:     file.Name,
+			Level:    file.Level,
+			Path:     tools.GetPathFromRoot(file.Path),
+			Rotation: newLoggerRotationConfig(file.Rotation),
+			Format:   newLoggerFormatConfig(file.Format),
+		})
+	}
+
+	var loggerClickHouseConfig *utils.LoggerClickHouseConfig
+	if config.Logger.ClickHouse.IsEnabled {
+		loggerClickHouseConfig = &utils.LoggerClickHouseConfig{
+			Level:         config.Logger.ClickHouse.Level,
+			Table:         config.Logger.ClickHouse.Table,
+			FlushInterval: time.Duration(config.Logger.ClickHouse.FlushInterval) * time.Second,
+			BatchSize:     config.Logger.ClickHouse.BatchSize,
+			BufferSize:    config.Logger.ClickHouse.BufferSize,
+			Connection:    NewClickHouseConfig(config),
+		}
+	}
+
+	var loggerTelegramConfig *utils.LoggerTelegramConfig
+	if config.Logger.Telegram.IsEnabled {
+		loggerTelegramConfig = &utils.LoggerTelegramConfig{
+			Level:       config.Logger.Telegram.Level,
+			DedupWindow: time.Duration(config.Logger.Telegram.DedupWindow) * time.Second,
+			RateLimit:   config.Logger.Telegram.RateLimit,
+			BufferSize:  config.Logger.Telegram.BufferSize,
+		}
+	}
+
+	var loggerSyslogConfig *utils.LoggerSyslogConfig
+	if config.Logger.Syslog.IsEnabled {
+		loggerSyslogConfig = &utils.LoggerSyslogConfig{
+			Level:             config.Logger.Syslog.Level,
+			Network:           config.Logger.Syslog.Network,
+			Address:           config.Logger.Syslog.Address,
+			Facility:          config.Logger.Syslog.Facility,
+			AppName:           config.Logger.Syslog.AppName,
+			Hostname:          config.Logger.Syslog.Hostname,
+			BufferSize:        config.Logger.Syslog.BufferSize,
+			ReconnectInterval: time.Duration(config.Logger.Syslog.ReconnectInterval) * time.Second,
+			Format:            newLoggerFormatConfig(config.Logger.Syslog.Format),
 		}
 	}
 
 	return &utils.LoggerConfig{
-		Console: loggerConsoleConfig,
-		File:    loggerFileConfig,
+		Console:    loggerConsoleConfig,
+		File:       loggerFileConfig,
+		Files:      loggerFilesConfigs,
+		ClickHouse: loggerClickHouseConfig,
+		Telegram:   loggerTelegramConfig,
+		Syslog:     loggerSyslogConfig,
+		Overrides:  config.Logger.Overrides,
+		Redaction: &utils.LoggerRedactionConfig{
+			FieldNames: config.Logger.Redaction.FieldNames,
+			Patterns:   config.Logger.Redaction.Patterns,
+		},
+	}
+}
+
+func newLoggerFormatConfig(format ConfigLoggerFormat) *utils.LoggerFormatConfig {
+	return &utils.LoggerFormatConfig{
+		Encoder:         format.Encoder,
+		Color:           format.Color,
+		TimeFormat:      format.TimeFormat,
+		CallerLevel:     format.CallerLevel,
+		StacktraceLevel: format.StacktraceLevel,
+	}
+}
+
+func newLoggerRotationConfig(rotation ConfigLoggerRotation) *utils.LoggerRotationConfig {
+	if !rotation.IsEnabled {
+		return nil
+	}
+
+	return &utils.LoggerRotationConfig{
+		MaxSize:      rotation.MaxSize,
+		MaxAge:       rotation.MaxAge,
+		MaxBackups:   rotation.MaxBackups,
+		MaxTotalSize: rotation.MaxTotalSize,
+		Localtime:    rotation.LocalTime,
+		Compress:     rotation.Compress,
+		Schedule:     rotation.Schedule,
+		Pattern:      rotation.Pattern,
+	}
+}
+
+func newRetryConfig(retry ConfigRetry) *tools.RetryConfig {
+	return &tools.RetryConfig{
+		MaxAttempts:     retry.MaxAttempts,
+		InitialInterval: time.Duration(retry.InitialInterval) * time.Millisecond,
+		MaxInterval:     time.Duration(retry.MaxInterval) * time.Millisecond,
+		Multiplier:      retry.Multiplier,
+		Jitter:          retry.Jitter,
+		MaxElapsedTime:  time.Duration(retry.MaxElapsedTime) * time.Second,
 	}
 }
 
 func NewPostgresqlConfig(config *Config) *utils.PostgresqlConfig {
 	return &utils.PostgresqlConfig{
-		Host:               config.Postgresql.Host,
-		Port:               config.Postgresql.Port,
-		User:               config.Postgresql.User,
-		Password:           config.Postgresql.Password,
-		Database:           config.Postgresql.Database,
-		IsNeedToRecreate:   config.Postgresql.IsNeedToRecreate,
-		AutoMigrate:        config.Postgresql.AutoMigrate,
-		IsNeedToInitialize: config.Postgresql.IsNeedToInitialize,
-		ConnMaxLifetime:    config.Postgresql.ConnMaxLifetime,
-		ConnMaxIdleTime:    config.Postgresql.ConnMaxIdleTime,
-		MaxIdleConns:       config.Postgresql.MaxIdleConns,
-		MaxOpenConns:       config.Postgresql.MaxOpenConns,
-		IsDebug:            config.IsDebug,
+		IsEnabled:           config.Postgresql.IsEnabled,
+		DSN:                 config.Postgresql.DSN,
+		Host:                config.Postgresql.Host,
+		Port:                config.Postgresql.Port,
+		User:                config.Postgresql.User,
+		Password:            config.Postgresql.Password,
+		Database:            config.Postgresql.Database,
+		IsNeedToRecreate:    config.Postgresql.IsNeedToRecreate,
+		AutoMigrate:         config.Postgresql.AutoMigrate,
+		IsNeedToInitialize:  config.Postgresql.IsNeedToInitialize,
+		ConnMaxLifetime:     config.Postgresql.ConnMaxLifetime,
+		ConnMaxIdleTime:     config.Postgresql.ConnMaxIdleTime,
+		MaxIdleConns:        config.Postgresql.MaxIdleConns,
+		MaxOpenConns:        config.Postgresql.MaxOpenConns,
+		IsDebug:             config.IsDebug,
+		ConnectRetry:        newRetryConfig(config.Postgresql.ConnectRetry),
+		IsRecreateConfirmed: config.Postgresql.IsRecreateConfirmed,
 	}
 }
 
 func NewRabbitMQConfig(config *Config) *utils.RabbitMQConfig {
 	return &utils.RabbitMQConfig{
-		Host:     config.RabbitMQ.Host,
-		Port:     config.RabbitMQ.Port,
-		User:     config.RabbitMQ.User,
-		Password: config.RabbitMQ.Password,
+		IsEnabled: config.RabbitMQ.IsEnabled,
+		URL:       config.RabbitMQ.URL,
+		Host:      config.RabbitMQ.Host,
+		Port:      config.RabbitMQ.Port,
+		User:      config.RabbitMQ.User,
+		Password:  config.RabbitMQ.Password,
 	}
 }
 
 func NewS3Config(config *Config) *utils.S3Config {
 	return &utils.S3Config{
+		IsEnabled:        config.S3.IsEnabled,
 		ConfigPaths:      config.S3.ConfigPaths,
 		CredentialsPaths: config.S3.CredentialsPaths,
 		ConfigFolder:     config.ConfigFolder,
@@ -241,6 +627,7 @@ func NewS3Config(config *Config) *utils.S3Config {
 
 func NewTelegramConfig(config *Config) *utils.TelegramConfig {
 	return &utils.TelegramConfig{
+		IsEnabled: config.Telegram.IsEnabled,
 		LongPoller: struct {
 			Timeout uint
 		}{
@@ -248,3 +635,24 @@ func NewTelegramConfig(config *Config) *utils.TelegramConfig {
 		},
 	}
 }
+
+func NewAdminHTTPConfig(config *Config) *utils.AdminHTTPConfig {
+	return &utils.AdminHTTPConfig{
+		IsEnabled: config.AdminHTTP.IsEnabled,
+		Address:   config.AdminHTTP.Address,
+		Token:     config.AdminHTTP.Token,
+	}
+}
+
+// NewCircuitBreakerConfig returns nil if circuit breakers are disabled, then calls are never rejected.
+func NewCircuitBreakerConfig(config *Config) *tools.CircuitBreakerConfig {
+	if !config.CircuitBreaker.IsEnabled {
+		return nil
+	}
+
+	return &tools.CircuitBreakerConfig{
+		FailureThreshold: config.CircuitBreaker.FailureThreshold,
+		OpenTimeout:      time.Duration(config.CircuitBreaker.OpenTimeout) * time.Second,
+		HalfOpenMaxCalls: config.CircuitBreaker.HalfOpenMaxCalls,
+	}
+}
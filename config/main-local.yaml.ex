@@ -1,3 +1,4 @@
+# yaml-language-server: $schema=main.schema.json
 "clickhouse":
   "database": "<REPLACE WITH YOURS>"
   "password": "<REPLACE WITH YOURS>"
@@ -5,7 +6,8 @@
   "auto_migrate": true
   "is_need_to_initialize": true
 "logger":
-  "level": "debug"
+  "console":
+    "level": "debug"
 "postgresql":
   "database": "<REPLACE WITH YOURS>"
   "password": "<REPLACE WITH YOURS>"
@@ -16,7 +18,6 @@
 "telegram":
   "token": "<REPLACE WITH YOURS>"
   "admins": []
-  "developers": []
 "rabbitmq":
   "password": "<REPLACE WITH YOURS>"
   "host": "<REPLACE WITH YOURS>"
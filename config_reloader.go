@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/managers"
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+// ErrConfigRestartRequired is returned when reloaded config changes values which can be applied only after restart.
+var ErrConfigRestartRequired = errors.New("restart required")
+
+// liveConfigPaths contains YAML paths of config values which can be changed without restart.
+var liveConfigPaths = map[string]bool{
+	"logger.console.level":    true,
+	"logger.file.level":       true,
+	"logger.clickhouse.level": true,
+	"logger.telegram.level":   true,
+	"logger.syslog.level":     true,
+	"logger.overrides":        true,
+	"telegram.admins":         true,
+	"s3_manager.max_keys":     true,
+	"s3_manager.timeout":      true,
+}
+
+// ConfigChangeHandler is called when a config section it is subscribed to is changed.
+type ConfigChangeHandler func(oldConfig *Config, newConfig *Config)
+
+// ConfigReloader watches config files and applies changes of values which do not require restart.
+//
+// Config passed to the constructor is never modified, use [ConfigReloader.GetConfig] to get the current one.
+type ConfigReloader struct {
+	logger      *zap.Logger
+	mutex       sync.Mutex
+	reloadMutex sync.Mutex
+	config      *Config
+	subscribers map[string][]ConfigChangeHandler
+}
+
+// NewConfigReloader creates a new [ConfigReloader] and subscribes logger levels to the logger section.
+// Other components are subscribed by application constructors,
+// see [ConfigReloader.SubscribeTelegramBotManager] and [ConfigReloader.SubscribeS3Manager].
+// Watching is started only if ConfigReload.IsEnabled is set.
+// Using for configuring with wire.
+func NewConfigReloader(
+	config *Config,
+	logger *zap.Logger,
+	loggerLevels *utils.LoggerLevels,
+) (*ConfigReloader, func()) {
+	r := &ConfigReloader{
+		logger:      logger.Named("ConfigReloader"),
+		config:      config,
+		subscribers: map[string][]ConfigChangeHandler{},
+	}
+
+	r.Subscribe("logger", func(_ *Config, newConfig *Config) {
+		sinkLevels := map[string]string{}
+		if newConfig.Logger.Console.IsEnabled {
+			sinkLevels[utils.LoggerSinkConsole] = newConfig.Logger.Console.Level
+		}
+
+		if newConfig.Logger.File.IsEnabled {
+			sinkLevels[utils.LoggerSinkFile] = newConfig.Logger.File.Level
+		}
+
+		if newConfig.Logger.ClickHouse.IsEnabled {
+			sinkLevels[utils.LoggerSinkClickHouse] = newConfig.Logger.ClickHouse.Level
+		}
+
+		if newConfig.Logger.Telegram.IsEnabled {
+			sinkLevels[utils.LoggerSinkTelegram] = newConfig.Logger.Telegram.Level
+		}
+
+		if newConfig.Logger.Syslog.IsEnabled {
+			sinkLevels[utils.LoggerSinkSyslog] = newConfig.Logger.Syslog.Level
+		}
+
+		for sink, level := range sinkLevels {
+			if err := loggerLevels.SetLevel(sink, level, 0); err != nil {
+				r.logger.Error("Failed to apply logger level", zap.Error(err))
+			}
+		}
+
+		if err := loggerLevels.SetOverrides(newConfig.Logger.Overrides); err != nil {
+			r.logger.Error("Failed to apply logger overrides", zap.Error(err))
+		}
+	})
+
+	if !config.ConfigReload.IsEnabled {
+		return r, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := tools.NewFileWatcher(
+		getConfigPaths(config.ConfigFolder, config.Profile),
+		time.Duration(config.ConfigReload.Interval)*time.Second,
+		func() { _ = r.Reload() },
+	)
+
+	go watcher.Run(ctx)
+
+	return r, cancel
+}
+
+// GetConfig returns the current config.
+func (r *ConfigReloader) GetConfig() *Config {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.config
+}
+
+// Subscribe adds handler which is called after any value of section is changed.
+// Section is a top-level YAML key of [Config], e.g. "telegram".
+func (r *ConfigReloader) Subscribe(section string, handler ConfigChangeHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.subscribers[section] = append(r.subscribers[section], handler)
+}
+
+// SubscribeTelegramBotManager applies changes of telegram section to telegramBotManager.
+func (r *ConfigReloader) SubscribeTelegramBotManager(telegramBotManager *managers.TelegramBotManager) {
+	r.Subscribe("telegram", func(_ *Config, newConfig *Config) {
+		telegramBotManager.SetAdmins(newConfig.Telegram.Admins)
+	})
+}
+
+// SubscribeS3Manager applies changes of s3_manager section to s3Manager.
+func (r *ConfigReloader) SubscribeS3Manager(s3Manager *managers.S3Manager) {
+	r.Subscribe("s3_manager", func(_ *Config, newConfig *Config) {
+		s3Manager.SetLimits(newConfig.S3Manager.MaxKeys, newConfig.S3Manager.Timeout)
+	})
+}
+
+// Reload reads config files again and notifies subscribers of changed sections.
+// If new config is invalid or changes values which require restart, it is rejected and the current config is kept.
+func (r *ConfigReloader) Reload() error {
+	ew := tools.GetErrorWrapper("ConfigReloader.Reload")
+	logger := r.logger.Named("Reload")
+
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	oldConfig := r.GetConfig()
+
+	newConfig, _, err := loadConfig(oldConfig.ConfigFolder, oldConfig.Profile)
+	if err != nil {
+		logger.Error("Failed to reload config", zap.Error(err))
+		return ew(err)
+	}
+
+	changedPaths := tools.DiffConfig(oldConfig, newConfig)
+
+	if len(changedPaths) == 0 {
+		return nil
+	}
+
+	restartRequiredPaths := []string{}
+	for _, path := range changedPaths {
+		if !liveConfigPaths[path] {
+			restartRequiredPaths = append(restartRequiredPaths, path)
+		}
+	}
+
+	if len(restartRequiredPaths) > 0 {
+		logger.Error("Config changes are rejected because they require restart",
+			zap.Strings("paths", restartRequiredPaths),
+		)
+
+		return ew(fmt.Errorf("%w: %s", ErrConfigRestartRequired, strings.Join(restartRequiredPaths, ", ")))
+	}
+
+	handlers := []ConfigChangeHandler{}
+	changedSections := []string{}
+
+	r.mutex.Lock()
+	r.config = newConfig
+
+	for _, path := range changedPaths {
+		section, _, _ := strings.Cut(path, ".")
+		if !slices.Contains(changedSections, section) {
+			changedSections = append(changedSections, section)
+			handlers = append(handlers, r.subscribers[section]...)
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(oldConfig, newConfig)
+	}
+
+	logger.Info("Config reloaded", zap.Strings("paths", changedPaths))
+
+	return nil
+}
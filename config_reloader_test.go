@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+// testReloaderConfig is a minimal valid config with placeholders for logger overrides,
+// telegram admins and postgresql host.
+const testReloaderConfig = `
+logger:
+  console:
+    is_enabled: true
+    level: info
+  overrides: %s
+telegram:
+  token: test
+  admins: %s
+s3_manager:
+  bucket: test
+postgresql:
+  host: %s
+`
+
+func newTestConfigReloader(t *testing.T) (*ConfigReloader, string) {
+	t.Helper()
+
+	configFolder := t.TempDir()
+	writeTestConfig(t, configFolder, "{ClickHouse: warn}", "[1]", "localhost")
+
+	config, _, err := loadConfig(configFolder, "")
+	require.NoError(t, err)
+
+	loggerLevels, err := utils.NewLoggerLevels(NewLoggerConfig(config))
+	require.NoError(t, err)
+
+	reloader, cleanup := NewConfigReloader(config, zap.NewNop(), loggerLevels)
+	t.Cleanup(cleanup)
+
+	return reloader, configFolder
+}
+
+func writeTestConfig(t *testing.T, configFolder string, overrides string, admins string, postgresqlHost string) {
+	t.Helper()
+
+	content := fmt.Sprintf(testReloaderConfig, overrides, admins, postgresqlHost)
+	require.NoError(t, os.WriteFile(filepath.Join(configFolder, "main.yaml"), []byte(content), 0o600))
+}
+
+func TestConfigReloaderAppliesLiveChanges(t *testing.T) {
+	reloader, configFolder := newTestConfigReloader(t)
+
+	var admins []int64
+	reloader.Subscribe("telegram", func(_ *Config, newConfig *Config) {
+		admins = newConfig.Telegram.Admins
+	})
+
+	writeTestConfig(t, configFolder, "{ClickHouse: warn}", "[1, 2]", "localhost")
+
+	require.NoError(t, reloader.Reload())
+	assert.Equal(t, []int64{1, 2}, admins)
+	assert.Equal(t, []int64{1, 2}, reloader.GetConfig().Telegram.Admins)
+}
+
+func TestConfigReloaderAppliesLoggerOverrides(t *testing.T) {
+	reloader, configFolder := newTestConfigReloader(t)
+
+	writeTestConfig(t, configFolder, "{ClickHouse: debug, Telegram: error}", "[1]", "localhost")
+
+	require.NoError(t, reloader.Reload())
+	assert.Equal(t, map[string]string{"ClickHouse": "debug", "Telegram": "error"}, reloader.GetConfig().Logger.Overrides)
+}
+
+func TestConfigReloaderRejectsRestartRequiredChanges(t *testing.T) {
+	reloader, configFolder := newTestConfigReloader(t)
+	oldConfig := reloader.GetConfig()
+
+	isCalled := false
+	reloader.Subscribe("postgresql", func(_ *Config, _ *Config) {
+		isCalled = true
+	})
+
+	writeTestConfig(t, configFolder, "{ClickHouse: warn}", "[1]", "db.example.com")
+
+	err := reloader.Reload()
+	require.ErrorIs(t, err, ErrConfigRestartRequired)
+	assert.ErrorContains(t, err, "postgresql.host")
+	assert.False(t, isCalled)
+	assert.Same(t, oldConfig, reloader.GetConfig())
+	assert.Equal(t, "localhost", reloader.GetConfig().Postgresql.Host)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/roman-kart/go-initial-project/v2/components/tools"
+	"github.com/roman-kart/go-initial-project/v2/components/utils"
+)
+
+//go:generate go run . config-schema --output config/main.schema.json
+
+// configSchemaPath is a path of the generated JSON Schema of [Config] relative to the project root.
+const configSchemaPath = "config/main.schema.json"
+
+// loggerOptionalLevelNames are values of caller and stacktrace levels of logger formats.
+var loggerOptionalLevelNames = append([]string{utils.LoggerLevelNone}, utils.ZapLevelNames...)
+
+// GenerateConfigSchema returns JSON Schema of [Config] formatted for writing to a file.
+func GenerateConfigSchema() ([]byte, error) {
+	ew := tools.GetErrorWrapper("GenerateConfigSchema")
+
+	schema, err := tools.GenerateConfigJSONSchema(Config{}, tools.ConfigSchemaOptions{
+		Title: "go-initial-project config",
+		Enums: map[string][]string{
+			"logger.console.level":                   utils.ZapLevelNames,
+			"logger.console.output":                  utils.LoggerOutputNames,
+			"logger.console.format.encoder":          utils.LoggerEncoderNames,
+			"logger.console.format.caller_level":     loggerOptionalLevelNames,
+			"logger.console.format.stacktrace_level": loggerOptionalLevelNames,
+			"logger.file.level":                      utils.ZapLevelNames,
+			"logger.file.format.encoder":             utils.LoggerEncoderNames,
+			"logger.file.format.caller_level":        loggerOptionalLevelNames,
+			"logger.file.format.stacktrace_level":    loggerOptionalLevelNames,
+			"logger.files.*.level":                   utils.ZapLevelNames,
+			"logger.files.*.format.encoder":          utils.LoggerEncoderNames,
+			"logger.files.*.format.caller_level":     loggerOptionalLevelNames,
+			"logger.files.*.format.stacktrace_level": loggerOptionalLevelNames,
+			"logger.file.rotation.schedule":          utils.LoggerRotationScheduleNames,
+			"logger.files.*.rotation.schedule":       utils.LoggerRotationScheduleNames,
+			"logger.clickhouse.level":                utils.ZapLevelNames,
+			"logger.telegram.level":                  utils.ZapLevelNames,
+			"logger.syslog.level":                    utils.ZapLevelNames,
+			"logger.syslog.network":                  utils.SyslogNetworkNames,
+			"logger.syslog.facility":                 utils.SyslogFacilityNames,
+			"logger.syslog.format.encoder":           utils.LoggerEncoderNames,
+			"logger.syslog.format.caller_level":      loggerOptionalLevelNames,
+			"logger.syslog.format.stacktrace_level":  loggerOptionalLevelNames,
+			"logger.overrides.*":                     utils.ZapLevelNames,
+		},
+	})
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, ew(err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// configSchemaCommand prints or writes JSON Schema of [Config].
+func configSchemaCommand(_ context.Context, args []string) error {
+	ew := tools.GetErrorWrapper("configSchemaCommand")
+
+	flags := flag.NewFlagSet("config-schema", flag.ContinueOnError)
+	output := flags.String("output", "", "file for writing the schema, stdout if empty")
+
+	if err := flags.Parse(args); err != nil {
+		return ew(err)
+	}
+
+	schema, err := GenerateConfigSchema()
+	if err != nil {
+		return ew(err)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(schema)
+		return ew(err)
+	}
+
+	return ew(os.WriteFile(*output, schema, 0o644)) //nolint:gosec
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchemaIsUpToDate(t *testing.T) {
+	expected, err := GenerateConfigSchema()
+	require.NoError(t, err)
+
+	actual, err := os.ReadFile(configSchemaPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(actual),
+		"%s is outdated, regenerate it with `go generate ./...` or `go run . config-schema --output %s`",
+		configSchemaPath, configSchemaPath,
+	)
+}
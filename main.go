@@ -3,6 +3,9 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/roman-kart/go-initial-project/v2/components/managers"
 	"github.com/roman-kart/go-initial-project/v2/components/tools"
@@ -14,14 +17,39 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		cmd, ok := commands[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
+			os.Exit(1)
+		}
+
+		// errors of the run are reported with its correlation ID
+		ctx, _ := tools.EnsureCorrelationID(context.Background())
+
+		err := tools.WrapMethodErrorContext(ctx, cmd(ctx, os.Args[2:]), os.Args[1])
+		// usage is already printed by the flag set on -h
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	test()
 }
 
 func configureApp(app *Application) error {
 	app.Logger.Info("Starting application")
 
+	if !app.TelegramBot.IsEnabled() {
+		app.Logger.Info("Telegram is disabled, bot commands are not configured")
+		return nil
+	}
+
 	helpAdditionalMessage := "Чтобы получить справку по конкретной команде: `/help <команда без слэша>`"
-	app.TelegramBotManager.AddCommonCommandsHandlers(&managers.CommonBotCommandsConfig{
+	err := app.TelegramBotManager.AddCommonCommandsHandlers(&managers.CommonBotCommandsConfig{
 		Start: managers.StartCommandConfig{
 			Enabled: true,
 			Message: "Привет!",
@@ -45,19 +73,29 @@ func configureApp(app *Application) error {
 			},
 		},
 	})
+	if err != nil {
+		return err
+	}
 
-	err := configureBot(app)
+	err = configureBot(app)
 
 	return err
 }
 
 func configureBot(app *Application) error {
 	botManager := app.TelegramBotManager
-	bot := botManager.GetBot()
+	bot, err := botManager.GetBot()
+	if err != nil {
+		return err
+	}
+
 	adminsOnlyGroup := bot.Group()
 	adminsOnlyGroup.Use(botManager.GetAdminOnlyMiddleware())
+	adminsOnlyGroup.Handle("/admins_log_levels", botManager.GetLogLevelsCommandHandler(app.LoggerLevels))
+	adminsOnlyGroup.Handle("/admins_circuit_breakers", botManager.GetCircuitBreakersCommandHandler(app.CircuitBreakers))
 	adminsOnlyGroup.Handle("/admins_s3", func(c telebot.Context) error {
-		ew := botManager.ErrorWrapperCreator.GetMethodWrapper("/admins_s3")
+		ctx := managers.TelegramContext(c)
+		ew := botManager.ErrorWrapperCreator.GetContextMethodWrapper(ctx, "/admins_s3")
 
 		if len(c.Args()) == 0 {
 			return ew(c.Send("Command is not specified"))
@@ -66,9 +104,11 @@ func configureBot(app *Application) error {
 		command := c.Args()[0]
 		switch command {
 		case "list":
-			objs, err := app.S3Manager.ListObjects(managers.ListObjectsInput{})
+			objs, err := app.S3Manager.ListObjectsContext(ctx, managers.ListObjectsInput{})
 			if err != nil {
-				return ew(c.Send("Error while listing objects"))
+				// the error is logged and the user gets a reply depending on its category
+				// (e.g. "Bucket does not exist") with its ID, see GetCorrelationMiddleware
+				return ew(err)
 			}
 
 			for _, obj := range objs {
@@ -98,9 +138,7 @@ func configureBot(app *Application) error {
 }
 
 func test() {
-	rootPath := tools.GetRootPath()
-	configFolder := rootPath + string(os.PathSeparator) + "config"
-	app, cleanup, err := InitializeApplication(configFolder, 1)
+	app, cleanup, err := InitializeApplication(getDefaultConfigFolder(), "", 1)
 
 	defer cleanup()
 
@@ -10,25 +10,41 @@ import (
 	"github.com/roman-kart/go-initial-project/v2/components/utils"
 )
 
+// configSet provides config and configs of components derived from it.
+var configSet = wire.NewSet(
+	NewConfig,
+
+	NewS3ManagerConfig,
+	NewTelegramBotManagerConfig,
+	NewClickHouseConfig,
+	NewLoggerConfig,
+	NewPostgresqlConfig,
+	NewRabbitMQConfig,
+	NewS3Config,
+	NewTelegramConfig,
+	NewAdminHTTPConfig,
+	NewCircuitBreakerConfig,
+)
+
+// loggerSet provides logger, error wrapping and circuit breakers, they are used by all components.
+var loggerSet = wire.NewSet(
+	tools.NewErrorWrapperCreator,
+	tools.NewCircuitBreakers,
+	utils.NewLogger,
+	utils.NewLoggerLevels,
+	utils.NewTelegramLogSenderProxy,
+)
+
 func InitializeApplication(
 	configFolder string,
+	profile ConfigProfile,
 	configCountdownSecondsCount uint,
 ) (*Application, func(), error) {
 	wire.Build(
-		NewConfig,
-
-		NewS3ManagerConfig,
-		NewTelegramBotManagerConfig,
-		NewClickHouseConfig,
-		NewLoggerConfig,
-		NewPostgresqlConfig,
-		NewRabbitMQConfig,
-		NewS3Config,
-		NewTelegramConfig,
-
-		tools.NewErrorWrapperCreator,
+		configSet,
+		loggerSet,
+
 		utils.NewClickHouse,
-		utils.NewLogger,
 		utils.NewPostgresql,
 		utils.NewRabbitMQ,
 		utils.NewS3,
@@ -39,7 +55,77 @@ func InitializeApplication(
 		managers.NewUserAccountManager,
 		managers.NewS3Manager,
 
+		utils.NewAdminHTTPServer,
+		NewConfigReloader,
 		NewApplication,
 	)
 	return &Application{}, func() {}, nil
 }
+
+// InitializeBotApplication builds components of a Telegram bot process, RabbitMQ is not constructed.
+func InitializeBotApplication(
+	configFolder string,
+	profile ConfigProfile,
+	configCountdownSecondsCount uint,
+) (*BotApplication, func(), error) {
+	wire.Build(
+		configSet,
+		loggerSet,
+
+		utils.NewClickHouse,
+		utils.NewPostgresql,
+		utils.NewS3,
+		utils.NewTelegram,
+
+		managers.NewStatManager,
+		managers.NewTelegramBotManager,
+		managers.NewUserAccountManager,
+		managers.NewS3Manager,
+
+		utils.NewAdminHTTPServer,
+		NewConfigReloader,
+		NewBotApplication,
+	)
+	return &BotApplication{}, func() {}, nil
+}
+
+// InitializeWorkerApplication builds components of a queue worker process, Telegram and S3 are not constructed.
+func InitializeWorkerApplication(
+	configFolder string,
+	profile ConfigProfile,
+	configCountdownSecondsCount uint,
+) (*WorkerApplication, func(), error) {
+	wire.Build(
+		configSet,
+		loggerSet,
+
+		utils.NewClickHouse,
+		utils.NewPostgresql,
+		utils.NewRabbitMQ,
+
+		managers.NewStatManager,
+		managers.NewUserAccountManager,
+
+		utils.NewAdminHTTPServer,
+		NewConfigReloader,
+		NewWorkerApplication,
+	)
+	return &WorkerApplication{}, func() {}, nil
+}
+
+// InitializeCLIApplication builds components of a console command, only Postgresql is connected.
+func InitializeCLIApplication(
+	configFolder string,
+	profile ConfigProfile,
+	configCountdownSecondsCount uint,
+) (*CLIApplication, func(), error) {
+	wire.Build(
+		configSet,
+		loggerSet,
+
+		utils.NewPostgresql,
+
+		NewCLIApplication,
+	)
+	return &CLIApplication{}, func() {}, nil
+}
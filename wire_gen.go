@@ -14,14 +14,19 @@ import (
 
 // Injectors from wire.go:
 
-func InitializeApplication(configFolder string, configCountdownSecondsCount uint) (*Application, func(), error) {
-	config, err := NewConfig(configFolder, configCountdownSecondsCount)
+func InitializeApplication(configFolder string, profile ConfigProfile, configCountdownSecondsCount uint) (*Application, func(), error) {
+	config, err := NewConfig(configFolder, profile, configCountdownSecondsCount)
 	if err != nil {
 		return nil, nil, err
 	}
 	clickHouseConfig := NewClickHouseConfig(config)
 	loggerConfig := NewLoggerConfig(config)
-	logger, cleanup, err := utils.NewLogger(loggerConfig)
+	loggerLevels, err := utils.NewLoggerLevels(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	telegramLogSenderProxy := utils.NewTelegramLogSenderProxy()
+	logger, cleanup, err := utils.NewLogger(loggerConfig, loggerLevels, telegramLogSenderProxy)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -44,7 +49,9 @@ func InitializeApplication(configFolder string, configCountdownSecondsCount uint
 	s3 := utils.NewS3(s3Config, logger, postgresql, errorWrapperCreator)
 	telegramConfig := NewTelegramConfig(config)
 	telegramBot := utils.NewTelegram(telegramConfig, logger, errorWrapperCreator)
-	statManager, err := managers.NewStatManager(logger, clickHouse, errorWrapperCreator)
+	circuitBreakerConfig := NewCircuitBreakerConfig(config)
+	circuitBreakers := tools.NewCircuitBreakers(circuitBreakerConfig, logger)
+	statManager, err := managers.NewStatManager(logger, clickHouse, errorWrapperCreator, circuitBreakers)
 	if err != nil {
 		cleanup3()
 		cleanup2()
@@ -52,14 +59,14 @@ func InitializeApplication(configFolder string, configCountdownSecondsCount uint
 		return nil, nil, err
 	}
 	telegramBotManagerConfig := NewTelegramBotManagerConfig(config)
-	userAccountManager, err := managers.NewUserAccountManager(logger, postgresql, errorWrapperCreator)
+	userAccountManager, err := managers.NewUserAccountManager(logger, postgresql, errorWrapperCreator, circuitBreakers)
 	if err != nil {
 		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
-	telegramBotManager, cleanup4, err := managers.NewTelegramBotManager(telegramBotManagerConfig, logger, statManager, userAccountManager, telegramBot, errorWrapperCreator)
+	telegramBotManager, cleanup4, err := managers.NewTelegramBotManager(telegramBotManagerConfig, logger, statManager, userAccountManager, telegramBot, telegramLogSenderProxy, errorWrapperCreator)
 	if err != nil {
 		cleanup3()
 		cleanup2()
@@ -67,19 +74,225 @@ func InitializeApplication(configFolder string, configCountdownSecondsCount uint
 		return nil, nil, err
 	}
 	s3ManagerConfig := NewS3ManagerConfig(config)
-	s3Manager, err := managers.NewS3Manager(s3ManagerConfig, logger, errorWrapperCreator, s3)
+	s3Manager, err := managers.NewS3Manager(s3ManagerConfig, logger, errorWrapperCreator, s3, circuitBreakers)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	configReloader, cleanup5 := NewConfigReloader(config, logger, loggerLevels)
+	adminHTTPConfig := NewAdminHTTPConfig(config)
+	adminHTTPServer, cleanup6, err := utils.NewAdminHTTPServer(adminHTTPConfig, logger, loggerLevels, errorWrapperCreator, circuitBreakers)
 	if err != nil {
+		cleanup5()
 		cleanup4()
 		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
-	application := NewApplication(config, clickHouse, logger, postgresql, rabbitMQ, s3, telegramBot, statManager, telegramBotManager, userAccountManager, s3Manager)
+	application := NewApplication(config, configReloader, clickHouse, logger, loggerLevels, adminHTTPServer, postgresql, rabbitMQ, s3, telegramBot, circuitBreakers, statManager, telegramBotManager, userAccountManager, s3Manager)
 	return application, func() {
+		cleanup6()
+		cleanup5()
 		cleanup4()
 		cleanup3()
 		cleanup2()
 		cleanup()
 	}, nil
 }
+
+func InitializeBotApplication(configFolder string, profile ConfigProfile, configCountdownSecondsCount uint) (*BotApplication, func(), error) {
+	config, err := NewConfig(configFolder, profile, configCountdownSecondsCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggerConfig := NewLoggerConfig(config)
+	loggerLevels, err := utils.NewLoggerLevels(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	telegramLogSenderProxy := utils.NewTelegramLogSenderProxy()
+	logger, cleanup, err := utils.NewLogger(loggerConfig, loggerLevels, telegramLogSenderProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	configReloader, cleanup2 := NewConfigReloader(config, logger, loggerLevels)
+	clickHouseConfig := NewClickHouseConfig(config)
+	errorWrapperCreator := tools.NewErrorWrapperCreator()
+	clickHouse, cleanup3, err := utils.NewClickHouse(clickHouseConfig, logger, errorWrapperCreator)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	circuitBreakerConfig := NewCircuitBreakerConfig(config)
+	circuitBreakers := tools.NewCircuitBreakers(circuitBreakerConfig, logger)
+	statManager, err := managers.NewStatManager(logger, clickHouse, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	telegramBotManagerConfig := NewTelegramBotManagerConfig(config)
+	postgresqlConfig := NewPostgresqlConfig(config)
+	postgresql, cleanup4, err := utils.NewPostgresql(postgresqlConfig, logger, errorWrapperCreator)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	userAccountManager, err := managers.NewUserAccountManager(logger, postgresql, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	telegramConfig := NewTelegramConfig(config)
+	telegramBot := utils.NewTelegram(telegramConfig, logger, errorWrapperCreator)
+	telegramBotManager, cleanup5, err := managers.NewTelegramBotManager(telegramBotManagerConfig, logger, statManager, userAccountManager, telegramBot, telegramLogSenderProxy, errorWrapperCreator)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	s3ManagerConfig := NewS3ManagerConfig(config)
+	s3Config := NewS3Config(config)
+	s3 := utils.NewS3(s3Config, logger, postgresql, errorWrapperCreator)
+	s3Manager, err := managers.NewS3Manager(s3ManagerConfig, logger, errorWrapperCreator, s3, circuitBreakers)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	adminHTTPConfig := NewAdminHTTPConfig(config)
+	adminHTTPServer, cleanup6, err := utils.NewAdminHTTPServer(adminHTTPConfig, logger, loggerLevels, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	botApplication := NewBotApplication(config, configReloader, logger, loggerLevels, adminHTTPServer, statManager, telegramBotManager, userAccountManager, s3Manager)
+	return botApplication, func() {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func InitializeWorkerApplication(configFolder string, profile ConfigProfile, configCountdownSecondsCount uint) (*WorkerApplication, func(), error) {
+	config, err := NewConfig(configFolder, profile, configCountdownSecondsCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggerConfig := NewLoggerConfig(config)
+	loggerLevels, err := utils.NewLoggerLevels(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	telegramLogSenderProxy := utils.NewTelegramLogSenderProxy()
+	logger, cleanup, err := utils.NewLogger(loggerConfig, loggerLevels, telegramLogSenderProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	configReloader, cleanup2 := NewConfigReloader(config, logger, loggerLevels)
+	postgresqlConfig := NewPostgresqlConfig(config)
+	errorWrapperCreator := tools.NewErrorWrapperCreator()
+	postgresql, cleanup3, err := utils.NewPostgresql(postgresqlConfig, logger, errorWrapperCreator)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	rabbitMQConfig := NewRabbitMQConfig(config)
+	rabbitMQ := utils.NewRabbitMQ(rabbitMQConfig, logger, errorWrapperCreator)
+	clickHouseConfig := NewClickHouseConfig(config)
+	clickHouse, cleanup4, err := utils.NewClickHouse(clickHouseConfig, logger, errorWrapperCreator)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	circuitBreakerConfig := NewCircuitBreakerConfig(config)
+	circuitBreakers := tools.NewCircuitBreakers(circuitBreakerConfig, logger)
+	statManager, err := managers.NewStatManager(logger, clickHouse, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	userAccountManager, err := managers.NewUserAccountManager(logger, postgresql, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	adminHTTPConfig := NewAdminHTTPConfig(config)
+	adminHTTPServer, cleanup5, err := utils.NewAdminHTTPServer(adminHTTPConfig, logger, loggerLevels, errorWrapperCreator, circuitBreakers)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	workerApplication := NewWorkerApplication(config, configReloader, logger, loggerLevels, adminHTTPServer, postgresql, rabbitMQ, statManager, userAccountManager)
+	return workerApplication, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func InitializeCLIApplication(configFolder string, profile ConfigProfile, configCountdownSecondsCount uint) (*CLIApplication, func(), error) {
+	config, err := NewConfig(configFolder, profile, configCountdownSecondsCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggerConfig := NewLoggerConfig(config)
+	loggerLevels, err := utils.NewLoggerLevels(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	telegramLogSenderProxy := utils.NewTelegramLogSenderProxy()
+	logger, cleanup, err := utils.NewLogger(loggerConfig, loggerLevels, telegramLogSenderProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	postgresqlConfig := NewPostgresqlConfig(config)
+	errorWrapperCreator := tools.NewErrorWrapperCreator()
+	postgresql, cleanup2, err := utils.NewPostgresql(postgresqlConfig, logger, errorWrapperCreator)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cliApplication := NewCLIApplication(config, logger, postgresql)
+	return cliApplication, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}